@@ -0,0 +1,51 @@
+// Command mockoidc is a minimal OpenID Connect provider for trying single
+// sign-on locally. It signs in a fixed user without asking for credentials,
+// so it must never be exposed.
+//
+//	go run ./cmd/mockoidc -groups superadmin
+//	OIDC_ISSUER_URL=http://localhost:9998 OIDC_CLIENT_ID=inventory \
+//	OIDC_ROLE_MAPPING=superadmin=superadmin \
+//	OIDC_REDIRECT_URL=http://localhost:8081/api/auth/oidc/callback go run ./cmd/server
+//
+// Then open http://localhost:8081/api/auth/oidc/login. The sub, username,
+// email, email_verified and groups query parameters on the authorize request
+// override the flags, to sign in as someone else.
+package main
+
+import (
+	"flag"
+	"go-rest/internal/mockoidc"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	addr := flag.String("addr", ":9998", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9998", "issuer URL")
+	clientID := flag.String("client-id", "inventory", "expected client ID")
+	clientSecret := flag.String("client-secret", "", "expected client secret, if any")
+	sub := flag.String("sub", "mock-user-1", "subject of the signed-in user")
+	username := flag.String("username", "mockuser", "preferred_username of the signed-in user")
+	email := flag.String("email", "mockuser@example.com", "verified email of the signed-in user")
+	groups := flag.String("groups", "", "comma-separated groups of the signed-in user")
+	amr := flag.String("amr", "pwd", "comma-separated authentication methods, e.g. pwd,mfa")
+	flag.Parse()
+
+	gin.SetMode(gin.ReleaseMode)
+	p, err := mockoidc.New(*issuer, *clientID, *clientSecret, url.Values{
+		"sub":      {*sub},
+		"username": {*username},
+		"email":    {*email},
+		"groups":   {*groups},
+		"amr":      {*amr},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Mock OIDC provider", p.Issuer, "listening on", *addr)
+	log.Fatal(http.ListenAndServe(*addr, p.Handler()))
+}
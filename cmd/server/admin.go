@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-rest/internal/config"
+	"go-rest/internal/database"
+	"go-rest/internal/services"
+	"log"
+	"os"
+)
+
+// runCreateAdmin handles `server create-admin -username NAME [-email EMAIL]`.
+// The password is read from ADMIN_PASSWORD so it stays out of the process
+// list; an existing user is promoted and keeps their password if it is unset.
+func runCreateAdmin(args []string) {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", "", "username of the admin")
+	email := fs.String("email", "", "email of the admin")
+	fs.Parse(args)
+
+	if *username == "" {
+		fmt.Fprintln(os.Stderr, "usage: ADMIN_PASSWORD=... server create-admin -username NAME [-email EMAIL]")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration! ", err)
+	}
+	db := database.ConnectDatabase(cfg.Database)
+	if cfg.AutoMigrate {
+		database.Migrate(db)
+	}
+
+	if err := services.RegisterAuditCallbacks(db); err != nil {
+		log.Fatal(err)
+	}
+
+	users := services.NewUserService(db, cfg, services.NewPermissionCache(db, 0))
+	user, err := users.CreateAdmin(services.NewUser{
+		Username: *username,
+		Password: os.Getenv("ADMIN_PASSWORD"),
+		Email:    *email,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s is now a member of %s\n", user.Username, cfg.Registration.AdminRole)
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	// Import generated docs
 	_ "go-rest/docs"
+	"go-rest/internal/config"
 	"go-rest/internal/database"
-	"go-rest/internal/routes"
-
-	"github.com/gin-gonic/gin"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 // @title           Inventory API
@@ -28,16 +31,47 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "create-admin":
+			runCreateAdmin(os.Args[2:])
+			return
+		}
+	}
+
 	println("Starting server...")
+	// Load and validate configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration! ", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration! ", err)
+	}
+
 	// Connect to database
-	database.ConnectDatabase()
+	db := database.ConnectDatabase(cfg.Database)
+
+	// Apply pending migrations unless they are run separately
+	if cfg.AutoMigrate {
+		database.Migrate(db)
+	}
 
-	// Initialize Router
-	r := gin.Default()
+	// Stop on SIGINT/SIGTERM, draining in-flight requests first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Setup Routes
-	routes.SetupRoutes(r)
+	server, err := NewServer(cfg, db)
+	if err != nil {
+		log.Fatal("Failed to initialize server! ", err)
+	}
 
 	// Start Server
-	r.Run(":8081")
+	if err := server.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Server stopped")
 }
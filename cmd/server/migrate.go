@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-rest/internal/config"
+	"go-rest/internal/database"
+	"go-rest/internal/database/migrations"
+	"log"
+	"os"
+)
+
+// runMigrate handles `server migrate up|down|status`.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: server migrate up|down [-steps N]|status")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration! ", err)
+	}
+	db := database.ConnectDatabase(cfg.Database)
+
+	switch args[0] {
+	case "up":
+		versions, err := migrations.Up(db)
+		for _, version := range versions {
+			fmt.Println("applied", version)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(versions) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		fs.Parse(args[1:])
+
+		versions, err := migrations.Down(db, *steps)
+		for _, version := range versions {
+			fmt.Println("rolled back", version)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrations.List(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s  %-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n", args[0])
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/config"
+	"go-rest/internal/handlers"
+	"go-rest/internal/middleware"
+	"go-rest/internal/routes"
+	"go-rest/internal/services"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Server wires configuration, the database and the HTTP router together.
+// Each Server owns its dependencies, so several can run in one process.
+type Server struct {
+	Config *config.Config
+	DB     *gorm.DB
+	Router *gin.Engine
+
+	workers    sync.WaitGroup
+	workerCtx  context.Context
+	stopWorker context.CancelFunc
+}
+
+func NewServer(cfg *config.Config, db *gorm.DB) (*Server, error) {
+	tokens, err := services.NewTokenService(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, err := services.NewNotifier(cfg.Notifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := services.RegisterAuditCallbacks(db); err != nil {
+		return nil, err
+	}
+
+	sessions := services.NewSessionService(db, cfg, tokens)
+	apiKeys := services.NewAPIKeyService(db)
+	permissions := services.NewPermissionCache(db, cfg.PermissionCacheTTL)
+	h := handlers.New(db, cfg, sessions, apiKeys, permissions, services.NewCloudinary(cfg.CloudinaryURL), notifier)
+
+	r := gin.Default()
+	r.Use(middleware.RequestID())
+	routes.SetupRoutes(r, h, middleware.AuthMiddleware(sessions, apiKeys, db, permissions), middleware.ScopeWarehouses(db))
+
+	// After the routes, so every permission they require is in the catalog
+	if err := h.Role.Roles.Seed(cfg.Registration.AdminRole, cfg.Registration.SeedRoles); err != nil {
+		return nil, err
+	}
+
+	s := &Server{Config: cfg, DB: db, Router: r}
+	s.workerCtx, s.stopWorker = context.WithCancel(context.Background())
+	s.Go(func(ctx context.Context) {
+		h.Inventory.Inventory.SweepReservations(ctx, cfg.Reservations.SweepInterval)
+	})
+	return s, nil
+}
+
+// Go starts a background worker. Its context is cancelled on shutdown and
+// Run waits for it to return before closing the database.
+func (s *Server) Go(worker func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		worker(s.workerCtx)
+	}()
+}
+
+// Run serves HTTP until ctx is cancelled, then drains in-flight requests,
+// stops background workers and closes the connection pool.
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.Config.Addr(),
+		Handler:           s.Router,
+		ReadTimeout:       s.Config.Server.ReadTimeout,
+		ReadHeaderTimeout: s.Config.Server.ReadHeaderTimeout,
+		WriteTimeout:      s.Config.Server.WriteTimeout,
+		IdleTimeout:       s.Config.Server.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Println("Listening on", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		log.Println("Shutting down...")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.Config.Server.ShutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+		err = errors.Join(err, shutdownErr)
+	}
+
+	s.stopWorker()
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		log.Println("Background workers did not stop in time")
+	}
+
+	if sqlDB, dbErr := s.DB.DB(); dbErr == nil {
+		if closeErr := sqlDB.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,529 @@
+// Package config loads the server configuration from an optional YAML file,
+// a .env file and the process environment, in increasing order of precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.yaml.in/yaml/v3"
+)
+
+type Config struct {
+	Port            string         `yaml:"port"`
+	JWT             JWTConfig      `yaml:"jwt"`
+	Registration    Registration   `yaml:"registration"`
+	Password        PasswordPolicy `yaml:"password"`
+	Login           LoginSecurity  `yaml:"login"`
+	PasswordReset   PasswordReset  `yaml:"password_reset"`
+	MFA             MFAConfig      `yaml:"mfa"`
+	OIDC            OIDCConfig     `yaml:"oidc"`
+	Notifier        NotifierConfig `yaml:"notifier"`
+	Reservations    Reservations   `yaml:"reservations"`
+	Adjustments     Adjustments    `yaml:"adjustments"`
+	AccessTokenTTL  time.Duration  `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration  `yaml:"refresh_token_ttl"`
+	// PermissionCacheTTL bounds how long a role's permissions and a user's
+	// roles are served from memory; 0 disables the cache.
+	PermissionCacheTTL time.Duration  `yaml:"permission_cache_ttl"`
+	CloudinaryURL      string         `yaml:"cloudinary_url"`
+	AutoMigrate        bool           `yaml:"auto_migrate"`
+	LowStockThreshold  int            `yaml:"low_stock_threshold"`
+	Server             ServerConfig   `yaml:"server"`
+	Database           DatabaseConfig `yaml:"database"`
+}
+
+type JWTConfig struct {
+	// Algorithm is HS256, RS256 or EdDSA.
+	Algorithm string `yaml:"algorithm"`
+	// Secret signs HS256 tokens; PrivateKeyFile holds the PEM key otherwise.
+	Secret         string `yaml:"secret"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	KeyID          string `yaml:"key_id"`
+	Issuer         string `yaml:"issuer"`
+	Audience       string `yaml:"audience"`
+	// VerificationKeys maps retired key IDs to files holding their PEM
+	// public key or raw HMAC secret. Tokens they signed stay valid until
+	// they expire.
+	VerificationKeys map[string]string `yaml:"verification_keys"`
+}
+
+// Registration controls who may create accounts and with which role.
+type Registration struct {
+	// Mode is open, where anyone may register, or invite, where only users
+	// allowed to write users can create accounts.
+	Mode string `yaml:"mode"`
+	// DefaultRole is given to new users. It is created without permissions
+	// if missing.
+	DefaultRole string `yaml:"default_role"`
+	// AdminRole is given to the first user when BootstrapAdmin is set, and
+	// by the create-admin command. It is created with every permission if
+	// missing.
+	AdminRole      string `yaml:"admin_role"`
+	BootstrapAdmin bool   `yaml:"bootstrap_admin"`
+	// SeedRoles creates the admin role and the built-in viewer, clerk and
+	// manager roles at startup when they are missing.
+	SeedRoles bool `yaml:"seed_roles"`
+}
+
+// PasswordPolicy is the strength every new password must meet.
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min_length"`
+	RequireUpper  bool `yaml:"require_upper"`
+	RequireLower  bool `yaml:"require_lower"`
+	RequireDigit  bool `yaml:"require_digit"`
+	RequireSymbol bool `yaml:"require_symbol"`
+}
+
+// LoginSecurity controls how failed logins are throttled.
+type LoginSecurity struct {
+	// MaxFailures failed logins for a username lock it for LockoutDuration.
+	MaxFailures int `yaml:"max_failures"`
+	// MaxFailuresPerIP failed logins from one address lock that address.
+	MaxFailuresPerIP int `yaml:"max_failures_per_ip"`
+	// Each failure for a username doubles the wait before the next attempt,
+	// starting at BackoffBase and capped at BackoffMax.
+	BackoffBase     time.Duration `yaml:"backoff_base"`
+	BackoffMax      time.Duration `yaml:"backoff_max"`
+	LockoutDuration time.Duration `yaml:"lockout_duration"`
+	// FailureWindow is how long a failure counts against a username or IP.
+	FailureWindow time.Duration `yaml:"failure_window"`
+}
+
+// PasswordReset controls the emailed password reset flow.
+type PasswordReset struct {
+	// TTL is how long a reset token stays valid.
+	TTL time.Duration `yaml:"ttl"`
+	// URL is the page that completes a reset; the token is appended as the
+	// token query parameter. When empty the bare token is sent.
+	URL string `yaml:"url"`
+}
+
+// Reservations controls how long stock may be held for pending orders.
+type Reservations struct {
+	// DefaultTTL applies when a reservation does not ask for one; no
+	// reservation may last longer than MaxTTL.
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+	MaxTTL     time.Duration `yaml:"max_ttl"`
+	// SweepInterval is how often expired reservations are released.
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+}
+
+// Adjustments controls manual stock adjustments.
+type Adjustments struct {
+	// Reasons are the reason codes an adjustment may give.
+	Reasons []string `yaml:"reasons"`
+	// ApprovalThreshold is the largest change, in units either way, applied
+	// straight away; larger ones wait for a user with inventory:approve.
+	ApprovalThreshold int `yaml:"approval_threshold"`
+}
+
+// MFAConfig controls TOTP two-factor authentication.
+type MFAConfig struct {
+	// Issuer is the account label shown in authenticator apps.
+	Issuer string `yaml:"issuer"`
+	// ChallengeTTL is how long a user has to enter their code after a
+	// correct password.
+	ChallengeTTL time.Duration `yaml:"challenge_ttl"`
+}
+
+// OIDCConfig enables single sign-on through an OpenID Connect provider.
+// SSO is off when IssuerURL is empty.
+type OIDCConfig struct {
+	IssuerURL    string   `yaml:"issuer_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+	// UsernameClaim names new users; GroupsClaim lists the user's groups.
+	UsernameClaim string `yaml:"username_claim"`
+	GroupsClaim   string `yaml:"groups_claim"`
+	// RoleMapping maps IdP groups to role names. Groups without a mapping
+	// grant no role, whatever they are called. When any group maps to a
+	// role, those roles replace the user's roles on every login.
+	RoleMapping map[string]string `yaml:"role_mapping"`
+	// DefaultRole is given to new users none of whose groups map to a
+	// role, instead of the registration default role.
+	DefaultRole string `yaml:"default_role"`
+	// LinkByEmail links a first SSO login to an existing user with the same
+	// email instead of creating a new user. Both the provider and this
+	// server must have verified the email.
+	LinkByEmail bool `yaml:"link_by_email"`
+}
+
+// NotifierConfig selects how messages such as reset links reach users.
+type NotifierConfig struct {
+	// Driver is log, which only logs that a message was sent, or file,
+	// which keeps whole messages, reset links included.
+	Driver string `yaml:"driver"`
+	// File receives messages when Driver is file.
+	File string `yaml:"file"`
+}
+
+type ServerConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests may drain.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"`
+	URL             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Port:               "8081",
+		AccessTokenTTL:     15 * time.Minute,
+		RefreshTokenTTL:    30 * 24 * time.Hour,
+		PermissionCacheTTL: time.Minute,
+		AutoMigrate:        true,
+		LowStockThreshold:  10,
+		JWT: JWTConfig{
+			Algorithm: "HS256",
+			Issuer:    "go-rest",
+			Audience:  "go-rest",
+		},
+		Registration: Registration{
+			Mode:           "open",
+			DefaultRole:    "user",
+			AdminRole:      "admin",
+			BootstrapAdmin: true,
+			SeedRoles:      true,
+		},
+		Password: PasswordPolicy{
+			MinLength:    8,
+			RequireUpper: true,
+			RequireLower: true,
+			RequireDigit: true,
+		},
+		Login: LoginSecurity{
+			MaxFailures:      5,
+			MaxFailuresPerIP: 20,
+			BackoffBase:      time.Second,
+			BackoffMax:       time.Minute,
+			LockoutDuration:  15 * time.Minute,
+			FailureWindow:    15 * time.Minute,
+		},
+		PasswordReset: PasswordReset{
+			TTL: time.Hour,
+		},
+		Reservations: Reservations{
+			DefaultTTL:    15 * time.Minute,
+			MaxTTL:        24 * time.Hour,
+			SweepInterval: time.Minute,
+		},
+		Adjustments: Adjustments{
+			Reasons:           []string{"damage", "theft", "found", "expired", "correction"},
+			ApprovalThreshold: 50,
+		},
+		MFA: MFAConfig{
+			Issuer:       "go-rest",
+			ChallengeTTL: 5 * time.Minute,
+		},
+		OIDC: OIDCConfig{
+			Scopes:        []string{"openid", "profile", "email"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+		},
+		Notifier: NotifierConfig{
+			Driver: "log",
+			File:   "notifications.log",
+		},
+		Server: ServerConfig{
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver: "sqlite",
+			URL:    "inventory.db",
+		},
+	}
+}
+
+// Load builds the configuration. The YAML file is read from CONFIG_FILE,
+// or config.yaml if present; a missing default file is not an error.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg := Default()
+
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = "config.yaml"
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	case explicit || !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) applyEnv() error {
+	setString(&cfg.Port, "PORT")
+	setString(&cfg.JWT.Algorithm, "JWT_ALGORITHM")
+	setString(&cfg.JWT.Secret, "JWT_SECRET")
+	setString(&cfg.JWT.PrivateKeyFile, "JWT_PRIVATE_KEY_FILE")
+	setString(&cfg.JWT.KeyID, "JWT_KEY_ID")
+	setString(&cfg.JWT.Issuer, "JWT_ISSUER")
+	setString(&cfg.JWT.Audience, "JWT_AUDIENCE")
+	if v := os.Getenv("JWT_VERIFICATION_KEYS"); v != "" {
+		cfg.JWT.VerificationKeys = parsePairs(v)
+	}
+	setString(&cfg.Registration.Mode, "REGISTRATION_MODE")
+	setString(&cfg.Registration.DefaultRole, "DEFAULT_ROLE")
+	setString(&cfg.Registration.AdminRole, "ADMIN_ROLE")
+	setString(&cfg.PasswordReset.URL, "PASSWORD_RESET_URL")
+	setString(&cfg.MFA.Issuer, "MFA_ISSUER")
+	setString(&cfg.OIDC.IssuerURL, "OIDC_ISSUER_URL")
+	setString(&cfg.OIDC.ClientID, "OIDC_CLIENT_ID")
+	setString(&cfg.OIDC.ClientSecret, "OIDC_CLIENT_SECRET")
+	setString(&cfg.OIDC.RedirectURL, "OIDC_REDIRECT_URL")
+	if v := os.Getenv("OIDC_SCOPES"); v != "" {
+		cfg.OIDC.Scopes = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+	if v := os.Getenv("ADJUSTMENT_REASONS"); v != "" {
+		cfg.Adjustments.Reasons = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+	setString(&cfg.OIDC.UsernameClaim, "OIDC_USERNAME_CLAIM")
+	setString(&cfg.OIDC.GroupsClaim, "OIDC_GROUPS_CLAIM")
+	if v := os.Getenv("OIDC_ROLE_MAPPING"); v != "" {
+		cfg.OIDC.RoleMapping = parsePairs(v)
+	}
+	setString(&cfg.OIDC.DefaultRole, "OIDC_DEFAULT_ROLE")
+	setString(&cfg.Notifier.Driver, "NOTIFIER")
+	setString(&cfg.Notifier.File, "NOTIFIER_FILE")
+	setString(&cfg.CloudinaryURL, "CLOUDINARY_URL")
+	setString(&cfg.Database.Driver, "DB_DRIVER")
+	setString(&cfg.Database.URL, "DATABASE_URL")
+
+	var errs []error
+	errs = append(errs,
+		setBool(&cfg.AutoMigrate, "AUTO_MIGRATE"),
+		setInt(&cfg.LowStockThreshold, "LOW_STOCK_THRESHOLD"),
+		setDuration(&cfg.AccessTokenTTL, "ACCESS_TOKEN_TTL"),
+		setDuration(&cfg.RefreshTokenTTL, "REFRESH_TOKEN_TTL"),
+		setDuration(&cfg.PermissionCacheTTL, "PERMISSION_CACHE_TTL"),
+		setInt(&cfg.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS"),
+		setInt(&cfg.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS"),
+		setDuration(&cfg.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME"),
+		setBool(&cfg.Registration.BootstrapAdmin, "BOOTSTRAP_ADMIN"),
+		setBool(&cfg.Registration.SeedRoles, "SEED_ROLES"),
+		setInt(&cfg.Password.MinLength, "PASSWORD_MIN_LENGTH"),
+		setBool(&cfg.Password.RequireUpper, "PASSWORD_REQUIRE_UPPER"),
+		setBool(&cfg.Password.RequireLower, "PASSWORD_REQUIRE_LOWER"),
+		setBool(&cfg.Password.RequireDigit, "PASSWORD_REQUIRE_DIGIT"),
+		setBool(&cfg.Password.RequireSymbol, "PASSWORD_REQUIRE_SYMBOL"),
+		setInt(&cfg.Login.MaxFailures, "LOGIN_MAX_FAILURES"),
+		setInt(&cfg.Login.MaxFailuresPerIP, "LOGIN_MAX_FAILURES_PER_IP"),
+		setDuration(&cfg.Login.BackoffBase, "LOGIN_BACKOFF_BASE"),
+		setDuration(&cfg.Login.BackoffMax, "LOGIN_BACKOFF_MAX"),
+		setDuration(&cfg.Login.LockoutDuration, "LOGIN_LOCKOUT_DURATION"),
+		setDuration(&cfg.Login.FailureWindow, "LOGIN_FAILURE_WINDOW"),
+		setDuration(&cfg.Reservations.DefaultTTL, "RESERVATION_TTL"),
+		setDuration(&cfg.Reservations.MaxTTL, "RESERVATION_MAX_TTL"),
+		setDuration(&cfg.Reservations.SweepInterval, "RESERVATION_SWEEP_INTERVAL"),
+		setInt(&cfg.Adjustments.ApprovalThreshold, "ADJUSTMENT_APPROVAL_THRESHOLD"),
+		setDuration(&cfg.PasswordReset.TTL, "PASSWORD_RESET_TTL"),
+		setDuration(&cfg.MFA.ChallengeTTL, "MFA_CHALLENGE_TTL"),
+		setBool(&cfg.OIDC.LinkByEmail, "OIDC_LINK_BY_EMAIL"),
+		setDuration(&cfg.Server.ReadTimeout, "HTTP_READ_TIMEOUT"),
+		setDuration(&cfg.Server.ReadHeaderTimeout, "HTTP_READ_HEADER_TIMEOUT"),
+		setDuration(&cfg.Server.WriteTimeout, "HTTP_WRITE_TIMEOUT"),
+		setDuration(&cfg.Server.IdleTimeout, "HTTP_IDLE_TIMEOUT"),
+		setDuration(&cfg.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT"),
+	)
+
+	// A DATABASE_URL without DB_DRIVER implies the driver from its scheme.
+	if os.Getenv("DB_DRIVER") == "" && os.Getenv("DATABASE_URL") != "" {
+		cfg.Database.Driver = driverFromURL(cfg.Database.URL)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every setting that would prevent the server from running.
+func (cfg *Config) Validate() error {
+	var errs []error
+	switch cfg.JWT.Algorithm {
+	case "HS256":
+		if cfg.JWT.Secret == "" {
+			errs = append(errs, errors.New("JWT_SECRET is required for HS256"))
+		}
+	case "RS256", "EdDSA":
+		if cfg.JWT.PrivateKeyFile == "" {
+			errs = append(errs, errors.New("JWT_PRIVATE_KEY_FILE is required for "+cfg.JWT.Algorithm))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.JWT.Algorithm))
+	}
+	if cfg.AccessTokenTTL <= 0 || cfg.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL must be positive"))
+	}
+	if cfg.PermissionCacheTTL < 0 {
+		errs = append(errs, errors.New("PERMISSION_CACHE_TTL must not be negative"))
+	}
+	if _, err := strconv.Atoi(cfg.Port); err != nil {
+		errs = append(errs, fmt.Errorf("PORT must be a number, got %q", cfg.Port))
+	}
+	switch cfg.Database.Driver {
+	case "sqlite", "postgres", "mysql":
+	default:
+		errs = append(errs, fmt.Errorf("unsupported DB_DRIVER %q", cfg.Database.Driver))
+	}
+	if cfg.Database.Driver != "sqlite" && cfg.Database.URL == "" {
+		errs = append(errs, errors.New("DATABASE_URL is required for "+cfg.Database.Driver))
+	}
+	switch cfg.Registration.Mode {
+	case "open", "invite":
+	default:
+		errs = append(errs, fmt.Errorf("unsupported REGISTRATION_MODE %q", cfg.Registration.Mode))
+	}
+	if cfg.Registration.DefaultRole == "" || cfg.Registration.AdminRole == "" {
+		errs = append(errs, errors.New("DEFAULT_ROLE and ADMIN_ROLE must not be empty"))
+	}
+	if cfg.Password.MinLength < 1 {
+		errs = append(errs, errors.New("PASSWORD_MIN_LENGTH must be at least 1"))
+	}
+	if cfg.Login.MaxFailures < 1 || cfg.Login.MaxFailuresPerIP < 1 {
+		errs = append(errs, errors.New("LOGIN_MAX_FAILURES and LOGIN_MAX_FAILURES_PER_IP must be at least 1"))
+	}
+	if cfg.PasswordReset.TTL <= 0 {
+		errs = append(errs, errors.New("PASSWORD_RESET_TTL must be positive"))
+	}
+	if cfg.Reservations.DefaultTTL <= 0 || cfg.Reservations.SweepInterval <= 0 {
+		errs = append(errs, errors.New("RESERVATION_TTL and RESERVATION_SWEEP_INTERVAL must be positive"))
+	}
+	if cfg.Reservations.MaxTTL < cfg.Reservations.DefaultTTL {
+		errs = append(errs, errors.New("RESERVATION_MAX_TTL must not be shorter than RESERVATION_TTL"))
+	}
+	if len(cfg.Adjustments.Reasons) == 0 {
+		errs = append(errs, errors.New("ADJUSTMENT_REASONS must list at least one reason"))
+	}
+	if cfg.Adjustments.ApprovalThreshold < 0 {
+		errs = append(errs, errors.New("ADJUSTMENT_APPROVAL_THRESHOLD must not be negative"))
+	}
+	if cfg.MFA.ChallengeTTL <= 0 {
+		errs = append(errs, errors.New("MFA_CHALLENGE_TTL must be positive"))
+	}
+	if cfg.OIDC.IssuerURL != "" && (cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "") {
+		errs = append(errs, errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER_URL is set"))
+	}
+	switch cfg.Notifier.Driver {
+	case "log":
+	case "file":
+		if cfg.Notifier.File == "" {
+			errs = append(errs, errors.New("NOTIFIER_FILE is required for the file notifier"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported NOTIFIER %q", cfg.Notifier.Driver))
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("SHUTDOWN_TIMEOUT must be positive"))
+	}
+	if cfg.LowStockThreshold < 0 {
+		errs = append(errs, errors.New("LOW_STOCK_THRESHOLD must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Addr is the listen address for the HTTP server.
+func (cfg *Config) Addr() string {
+	return ":" + cfg.Port
+}
+
+func driverFromURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
+		return "postgres"
+	case strings.HasPrefix(url, "mysql://"):
+		return "mysql"
+	default:
+		return "sqlite"
+	}
+}
+
+// parsePairs parses "a=1,b=2" into a map.
+func parsePairs(v string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok {
+			pairs[key] = value
+		}
+	}
+	return pairs
+}
+
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func setBool(dst *bool, key string) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = b
+	return nil
+}
+
+func setInt(dst *int, key string) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*dst = d
+	return nil
+}
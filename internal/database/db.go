@@ -1,34 +1,79 @@
 package database
 
 import (
-	"go-rest/internal/models"
+	"go-rest/internal/config"
+	"go-rest/internal/database/migrations"
 	"log"
 
 	"github.com/glebarez/sqlite"
-	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-func ConnectDatabase() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+// ConnectDatabase opens the configured database and sizes its pool.
+func ConnectDatabase(cfg config.DatabaseConfig) *gorm.DB {
+	dialector, err := Dialector(cfg.Driver, cfg.URL)
+	if err != nil {
+		log.Fatal("Failed to configure database! ", err)
 	}
 
-	database, err := gorm.Open(sqlite.Open("inventory.db"), &gorm.Config{})
+	database, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database!", err)
 	}
 
-	// Drop tables to migrate to UUID
-	// WARNING: This deletes all data!
-	database.Migrator().DropTable(&models.Item{}, &models.User{}, &models.Warehouse{}, &models.Supplier{}, &models.Discount{}, &models.Media{}, &models.Variant{}, &models.Option{}, &models.Review{}, &models.Favorite{}, &models.Inventory{}, &models.Category{}, &models.PurchaseOrder{}, &models.PurchaseOrderItem{}, &models.Order{}, &models.OrderItem{}, &models.Role{}, &models.Permission{})
+	sqlDB, err := database.DB()
+	if err != nil {
+		log.Fatal("Failed to get database pool!", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	return database
+}
 
-	err = database.AutoMigrate(&models.Item{}, &models.User{}, &models.Warehouse{}, &models.Supplier{}, &models.Discount{}, &models.Media{}, &models.Variant{}, &models.Option{}, &models.Review{}, &models.Favorite{}, &models.Inventory{}, &models.Category{}, &models.PurchaseOrder{}, &models.PurchaseOrderItem{}, &models.Order{}, &models.OrderItem{}, &models.Role{}, &models.Permission{})
+// Dialector returns the GORM dialector for the given driver name.
+// An empty DSN means the local SQLite file for sqlite.
+func Dialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case "", "sqlite":
+		if dsn == "" {
+			dsn = "inventory.db"
+		}
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	case "postgres":
+		return postgres.Open(dsn), nil
+	case "mysql":
+		return newMySQLDialector(dsn)
+	default:
+		return nil, &UnsupportedDriverError{Driver: driver}
+	}
+}
+
+// UnsupportedDriverError is returned for an unknown DB_DRIVER.
+type UnsupportedDriverError struct {
+	Driver string
+}
+
+func (e *UnsupportedDriverError) Error() string {
+	return "unsupported database driver: " + e.Driver
+}
+
+// Migrate applies any pending schema migrations.
+func Migrate(db *gorm.DB) {
+	versions, err := migrations.Up(db)
 	if err != nil {
 		log.Fatal("Failed to migrate database!", err)
 	}
 
-	DB = database
+	for _, version := range versions {
+		log.Println("Applied migration", version)
+	}
 }
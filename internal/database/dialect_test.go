@@ -0,0 +1,43 @@
+package database
+
+import "testing"
+
+func TestMySQLDSN(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"mysql://app:secret@db:3306/inventory", "app:secret@tcp(db:3306)/inventory?parseTime=true"},
+		{"mysql://app:p%40ss@db:3306/inventory?charset=utf8mb4&loc=Local", "app:p@ss@tcp(db:3306)/inventory?loc=Local&parseTime=true&charset=utf8mb4"},
+		{"mysql://app@localhost/inventory", "app@tcp(localhost)/inventory?parseTime=true"},
+		{"app:secret@tcp(db:3306)/inventory?parseTime=true", "app:secret@tcp(db:3306)/inventory?parseTime=true"},
+	}
+	for _, test := range tests {
+		got, err := mysqlDSN(test.url)
+		if err != nil {
+			t.Errorf("%s: %v", test.url, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: DSN = %s, want %s", test.url, got, test.want)
+		}
+	}
+
+	if _, err := mysqlDSN("mysql://app@db/inventory?timeout=soon"); err == nil {
+		t.Error("accepted an invalid driver parameter")
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		dsn, want string
+	}{
+		{"inventory.db", "inventory.db?_pragma=busy_timeout(10000)&_txlock=immediate"},
+		{"sqlite://data/inventory.db?_pragma=foreign_keys(1)", "data/inventory.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)&_txlock=immediate"},
+		{"inventory.db?_pragma=busy_timeout(500)&_txlock=deferred", "inventory.db?_pragma=busy_timeout(500)&_txlock=deferred"},
+	}
+	for _, test := range tests {
+		if got := sqliteDSN(test.dsn); got != test.want {
+			t.Errorf("%s: DSN = %s, want %s", test.dsn, got, test.want)
+		}
+	}
+}
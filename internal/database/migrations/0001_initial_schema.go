@@ -0,0 +1,236 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Base is models.Base as it was when this migration was written.
+type Base struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate gives rows that migrations insert a UUID, as models.Base does.
+func (b *Base) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// The tables that existed before versioned migrations, as the models
+// defined them then. Databases created by the old AutoMigrate-on-boot
+// startup already have them, which is fine: AutoMigrate only creates what is
+// missing.
+
+type item0001 struct {
+	Base
+	Name          string
+	Description   string
+	Price         float64
+	CategoryID    uuid.UUID
+	SupplierID    uuid.UUID
+	ViewerCount   int
+	FavoriteCount int
+	Media         []media0001   `gorm:"foreignKey:ItemID"`
+	Variants      []variant0001 `gorm:"foreignKey:ItemID"`
+	Reviews       []review0001  `gorm:"foreignKey:ItemID"`
+}
+
+func (item0001) TableName() string { return "items" }
+
+type media0001 struct {
+	Base
+	ItemID   uuid.UUID
+	Type     string
+	URL      string
+	PublicID string
+}
+
+func (media0001) TableName() string { return "media" }
+
+type variant0001 struct {
+	Base
+	ItemID  uuid.UUID
+	Name    string
+	Options []option0001 `gorm:"foreignKey:VariantID"`
+}
+
+func (variant0001) TableName() string { return "variants" }
+
+type option0001 struct {
+	Base
+	VariantID uuid.UUID
+	Name      string
+}
+
+func (option0001) TableName() string { return "options" }
+
+type review0001 struct {
+	Base
+	UserID  uuid.UUID
+	ItemID  uuid.UUID
+	Rating  int
+	Comment string
+}
+
+func (review0001) TableName() string { return "reviews" }
+
+type favorite0001 struct {
+	Base
+	UserID uuid.UUID
+	ItemID uuid.UUID
+}
+
+func (favorite0001) TableName() string { return "favorites" }
+
+type user0001 struct {
+	Base
+	Username string `gorm:"unique"`
+	Password string
+	RoleID   uuid.UUID
+	Role     role0001 `gorm:"foreignKey:RoleID"`
+}
+
+func (user0001) TableName() string { return "users" }
+
+type role0001 struct {
+	Base
+	Name        string `gorm:"unique"`
+	Description string
+}
+
+func (role0001) TableName() string { return "roles" }
+
+type rolePermission0001 struct {
+	RoleID       uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	PermissionID uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	Role         role0001       `gorm:"foreignKey:RoleID"`
+	Permission   permission0001 `gorm:"foreignKey:PermissionID"`
+}
+
+func (rolePermission0001) TableName() string { return "role_permissions" }
+
+type permission0001 struct {
+	Base
+	Resource string
+	Action   string
+}
+
+func (permission0001) TableName() string { return "permissions" }
+
+type warehouse0001 struct {
+	Base
+	Name     string
+	Location string
+	Capacity int
+}
+
+func (warehouse0001) TableName() string { return "warehouses" }
+
+type supplier0001 struct {
+	Base
+	Name        string
+	ContactInfo string
+	Address     string
+}
+
+func (supplier0001) TableName() string { return "suppliers" }
+
+type discount0001 struct {
+	Base
+	Name       string
+	Percentage float64
+	StartDate  time.Time
+	EndDate    time.Time
+	Active     bool
+}
+
+func (discount0001) TableName() string { return "discounts" }
+
+type category0001 struct {
+	Base
+	Name        string
+	Description string
+}
+
+func (category0001) TableName() string { return "categories" }
+
+type inventory0001 struct {
+	Base
+	ItemID      uuid.UUID
+	WarehouseID uuid.UUID
+	Quantity    int
+}
+
+func (inventory0001) TableName() string { return "inventories" }
+
+type purchaseOrder0001 struct {
+	Base
+	SupplierID  uuid.UUID
+	WarehouseID uuid.UUID
+	Status      string
+	TotalAmount float64
+	Date        time.Time
+	Items       []purchaseOrderItem0001 `gorm:"foreignKey:PurchaseOrderID"`
+}
+
+func (purchaseOrder0001) TableName() string { return "purchase_orders" }
+
+type purchaseOrderItem0001 struct {
+	Base
+	PurchaseOrderID uuid.UUID
+	ItemID          uuid.UUID
+	Quantity        int
+	UnitPrice       float64
+}
+
+func (purchaseOrderItem0001) TableName() string { return "purchase_order_items" }
+
+type order0001 struct {
+	Base
+	UserID        uuid.UUID
+	WarehouseID   uuid.UUID
+	TotalAmount   float64
+	Status        string
+	PaymentMethod string
+	Date          time.Time
+	Items         []orderItem0001 `gorm:"foreignKey:OrderID"`
+}
+
+func (order0001) TableName() string { return "orders" }
+
+type orderItem0001 struct {
+	Base
+	OrderID   uuid.UUID
+	ItemID    uuid.UUID
+	Quantity  int
+	UnitPrice float64
+}
+
+func (orderItem0001) TableName() string { return "order_items" }
+
+var initialTables = []interface{}{
+	&item0001{}, &user0001{}, &warehouse0001{}, &supplier0001{}, &discount0001{},
+	&media0001{}, &variant0001{}, &option0001{}, &review0001{}, &favorite0001{},
+	&inventory0001{}, &category0001{}, &purchaseOrder0001{}, &purchaseOrderItem0001{},
+	&order0001{}, &orderItem0001{}, &role0001{}, &permission0001{}, &rolePermission0001{},
+}
+
+func init() {
+	register(Migration{
+		Version: "0001",
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(initialTables...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(initialTables...)
+		},
+	})
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type session0002 struct {
+	Base
+	UserID    uuid.UUID `gorm:"index"`
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	UserAgent string
+	IP        string
+}
+
+func (session0002) TableName() string { return "sessions" }
+
+type refreshToken0002 struct {
+	Base
+	SessionID    uuid.UUID `gorm:"index"`
+	UserID       uuid.UUID
+	TokenHash    string `gorm:"uniqueIndex;size:64"`
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	ReplacedByID *uuid.UUID
+}
+
+func (refreshToken0002) TableName() string { return "refresh_tokens" }
+
+func init() {
+	register(Migration{
+		Version: "0002",
+		Name:    "sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&session0002{}, &refreshToken0002{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&refreshToken0002{}, &session0002{})
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type apiKey0003 struct {
+	Base
+	UserID     uuid.UUID `gorm:"index"`
+	Name       string
+	Prefix     string
+	KeyHash    string `gorm:"uniqueIndex;size:64"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (apiKey0003) TableName() string { return "api_keys" }
+
+type apiKeyPermission0003 struct {
+	APIKeyID     uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	PermissionID uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	APIKey       apiKey0003     `gorm:"foreignKey:APIKeyID"`
+	Permission   permission0001 `gorm:"foreignKey:PermissionID"`
+}
+
+func (apiKeyPermission0003) TableName() string { return "api_key_permissions" }
+
+func init() {
+	register(Migration{
+		Version: "0003",
+		Name:    "api_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&apiKey0003{}, &apiKeyPermission0003{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&apiKeyPermission0003{}, &apiKey0003{})
+		},
+	})
+}
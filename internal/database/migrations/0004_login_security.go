@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type loginThrottle0004 struct {
+	Base
+	Target        string `gorm:"uniqueIndex;size:255"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+func (loginThrottle0004) TableName() string { return "login_throttles" }
+
+type securityEvent0004 struct {
+	Base
+	Type     string     `gorm:"index"`
+	UserID   *uuid.UUID `gorm:"index"`
+	Username string
+	IP       string
+	Details  string
+}
+
+func (securityEvent0004) TableName() string { return "security_events" }
+
+func init() {
+	register(Migration{
+		Version: "0004",
+		Name:    "login_security",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&loginThrottle0004{}, &securityEvent0004{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&loginThrottle0004{}, &securityEvent0004{})
+		},
+	})
+}
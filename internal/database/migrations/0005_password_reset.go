@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// user0005 is the column this migration adds to users.
+type user0005 struct {
+	Email *string `gorm:"uniqueIndex;size:255"`
+}
+
+func (user0005) TableName() string { return "users" }
+
+type passwordReset0005 struct {
+	Base
+	UserID    uuid.UUID `gorm:"index"`
+	TokenHash string    `gorm:"uniqueIndex;size:64"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (passwordReset0005) TableName() string { return "password_resets" }
+
+func init() {
+	register(Migration{
+		Version: "0005",
+		Name:    "password_reset",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&user0005{}, &passwordReset0005{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&passwordReset0005{}); err != nil {
+				return err
+			}
+			if tx.Migrator().HasIndex(&user0005{}, "Email") {
+				if err := tx.Migrator().DropIndex(&user0005{}, "Email"); err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &user0005{}, "Email")
+		},
+	})
+}
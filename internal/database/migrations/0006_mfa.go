@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// user0006, role0006 and session0006 are the columns this migration adds.
+type user0006 struct {
+	MFAEnabled  bool
+	MFASecret   string
+	MFALastStep int64
+}
+
+func (user0006) TableName() string { return "users" }
+
+type role0006 struct {
+	RequireMFA bool
+}
+
+func (role0006) TableName() string { return "roles" }
+
+type session0006 struct {
+	MFA bool
+}
+
+func (session0006) TableName() string { return "sessions" }
+
+type recoveryCode0006 struct {
+	Base
+	UserID   uuid.UUID `gorm:"index"`
+	CodeHash string    `gorm:"uniqueIndex;size:64"`
+	UsedAt   *time.Time
+}
+
+func (recoveryCode0006) TableName() string { return "recovery_codes" }
+
+func init() {
+	register(Migration{
+		Version: "0006",
+		Name:    "mfa",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&user0006{}, &role0006{}, &session0006{}, &recoveryCode0006{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&recoveryCode0006{}); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, &user0006{}, "MFAEnabled", "MFASecret", "MFALastStep"); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, &role0006{}, "RequireMFA"); err != nil {
+				return err
+			}
+			return dropColumns(tx, &session0006{}, "MFA")
+		},
+	})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0007 holds the columns this migration adds to users.
+type user0007 struct {
+	OIDCIssuer  *string `gorm:"column:oidc_issuer;uniqueIndex:idx_users_oidc;size:255"`
+	OIDCSubject *string `gorm:"column:oidc_subject;uniqueIndex:idx_users_oidc;size:255"`
+}
+
+func (user0007) TableName() string { return "users" }
+
+type oidcState0007 struct {
+	Base
+	StateHash    string `gorm:"uniqueIndex;size:64"`
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+func (oidcState0007) TableName() string { return "oidc_states" }
+
+func init() {
+	register(Migration{
+		Version: "0007",
+		Name:    "oidc",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&user0007{}, &oidcState0007{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&oidcState0007{}); err != nil {
+				return err
+			}
+			if tx.Migrator().HasIndex(&user0007{}, "idx_users_oidc") {
+				if err := tx.Migrator().DropIndex(&user0007{}, "idx_users_oidc"); err != nil {
+					return err
+				}
+			}
+			return dropColumns(tx, &user0007{}, "OIDCIssuer", "OIDCSubject")
+		},
+	})
+}
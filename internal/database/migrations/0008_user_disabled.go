@@ -0,0 +1,23 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// user0008 is the column this migration adds to users.
+type user0008 struct {
+	Disabled bool
+}
+
+func (user0008) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: "0008",
+		Name:    "user_disabled",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&user0008{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &user0008{}, "Disabled")
+		},
+	})
+}
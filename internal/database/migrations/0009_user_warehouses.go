@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// userWarehouse0009 is the join table between users and the warehouses
+// they may work in.
+type userWarehouse0009 struct {
+	UserID      uuid.UUID     `gorm:"type:uuid;primaryKey"`
+	WarehouseID uuid.UUID     `gorm:"type:uuid;primaryKey"`
+	User        user0001      `gorm:"foreignKey:UserID"`
+	Warehouse   warehouse0001 `gorm:"foreignKey:WarehouseID"`
+}
+
+func (userWarehouse0009) TableName() string { return "user_warehouses" }
+
+// allWarehouses0009 is the permission that lets a role work in every
+// warehouse. Before this migration every role could.
+var allWarehouses0009 = permission0001{Resource: "all_warehouses", Action: "access"}
+
+func init() {
+	register(Migration{
+		Version: "0009",
+		Name:    "user_warehouses",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&userWarehouse0009{}); err != nil {
+				return err
+			}
+			return grantAllWarehouses(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			var permission permission0001
+			err := tx.Where(&allWarehouses0009).Limit(1).Find(&permission).Error
+			if err != nil {
+				return err
+			}
+			if permission.ID != uuid.Nil {
+				if err := tx.Where("permission_id = ?", permission.ID).Delete(&rolePermission0001{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Unscoped().Delete(&permission).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&userWarehouse0009{})
+		},
+	})
+}
+
+// grantAllWarehouses gives every existing role the warehouse bypass, so
+// scoping does not lock anyone out of the warehouses they used until an
+// admin assigns them.
+func grantAllWarehouses(tx *gorm.DB) error {
+	var roleIDs []uuid.UUID
+	if err := tx.Model(&role0001{}).Pluck("id", &roleIDs).Error; err != nil {
+		return err
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	permission := allWarehouses0009
+	if err := tx.Where(&allWarehouses0009).FirstOrCreate(&permission).Error; err != nil {
+		return err
+	}
+	grants := make([]rolePermission0001, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		grants = append(grants, rolePermission0001{RoleID: roleID, PermissionID: permission.ID})
+	}
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&grants).Error
+}
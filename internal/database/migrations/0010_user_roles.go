@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// role0010 is the column this migration adds to roles.
+type role0010 struct {
+	ParentID *uuid.UUID
+}
+
+func (role0010) TableName() string { return "roles" }
+
+// userRole0010 is the join table that replaces each user's single role.
+type userRole0010 struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RoleID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	User   user0001  `gorm:"foreignKey:UserID"`
+	Role   role0001  `gorm:"foreignKey:RoleID"`
+}
+
+func (userRole0010) TableName() string { return "user_roles" }
+
+// legacyUser is the users table as it was when every user had one role,
+// with the foreign key on it.
+type legacyUser struct {
+	RoleID uuid.UUID
+	Role   role0001 `gorm:"foreignKey:RoleID"`
+}
+
+func (legacyUser) TableName() string {
+	return "users"
+}
+
+func init() {
+	register(Migration{
+		Version: "0010",
+		Name:    "user_roles",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&role0010{}, &userRole0010{}); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasColumn(&legacyUser{}, "RoleID") {
+				return nil
+			}
+
+			err := tx.Exec(`INSERT INTO user_roles (user_id, role_id)
+				SELECT users.id, users.role_id FROM users
+				WHERE users.role_id IN (SELECT id FROM roles)`).Error
+			if err != nil {
+				return err
+			}
+			return keepIndexes(tx, &legacyUser{}, func() error {
+				// The column cannot go while its foreign key still refers to it
+				if tx.Migrator().HasConstraint(&legacyUser{}, "Role") {
+					if err := tx.Migrator().DropConstraint(&legacyUser{}, "Role"); err != nil {
+						return err
+					}
+				}
+				return tx.Migrator().DropColumn(&legacyUser{}, "RoleID")
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&legacyUser{}, "RoleID"); err != nil {
+				return err
+			}
+			err := keepIndexes(tx, &legacyUser{}, func() error {
+				return tx.Migrator().CreateConstraint(&legacyUser{}, "Role")
+			})
+			if err != nil {
+				return err
+			}
+			// A user keeps one of their roles; which one is arbitrary
+			err = tx.Exec(`UPDATE users SET role_id = (
+				SELECT user_roles.role_id FROM user_roles
+				WHERE user_roles.user_id = users.id
+				ORDER BY user_roles.role_id LIMIT 1)`).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropTable(&userRole0010{}); err != nil {
+				return err
+			}
+			return dropColumns(tx, &role0010{}, "ParentID")
+		},
+	})
+}
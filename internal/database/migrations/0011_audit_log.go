@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type auditLog0011 struct {
+	Base
+	ActorID    *uuid.UUID             `gorm:"index"`
+	Action     string                 `gorm:"index"`
+	EntityType string                 `gorm:"index"`
+	EntityID   string                 `gorm:"index"`
+	Before     map[string]interface{} `gorm:"serializer:json;type:text"`
+	After      map[string]interface{} `gorm:"serializer:json;type:text"`
+	RequestID  string                 `gorm:"index"`
+	IP         string
+}
+
+func (auditLog0011) TableName() string { return "audit_logs" }
+
+func init() {
+	register(Migration{
+		Version: "0011",
+		Name:    "audit_log",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&auditLog0011{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&auditLog0011{})
+		},
+	})
+}
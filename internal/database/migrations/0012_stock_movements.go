@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type stockMovement0012 struct {
+	Base
+	ItemID        uuid.UUID `gorm:"index:idx_stock_movements_stock"`
+	WarehouseID   uuid.UUID `gorm:"index:idx_stock_movements_stock"`
+	Type          string    `gorm:"index"`
+	Quantity      int
+	Balance       int
+	ReferenceType string
+	ReferenceID   *uuid.UUID `gorm:"index"`
+	UserID        *uuid.UUID
+	Note          string
+}
+
+func (stockMovement0012) TableName() string { return "stock_movements" }
+
+func init() {
+	register(Migration{
+		Version: "0012",
+		Name:    "stock_movements",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&stockMovement0012{}); err != nil {
+				return err
+			}
+
+			// Open the ledger with the stock already on hand, so it balances
+			var stock []inventory0001
+			err := tx.Where("quantity <> 0").
+				Where("NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.item_id = inventories.item_id AND stock_movements.warehouse_id = inventories.warehouse_id)").
+				Find(&stock).Error
+			if err != nil {
+				return err
+			}
+			for _, inv := range stock {
+				opening := stockMovement0012{
+					ItemID:      inv.ItemID,
+					WarehouseID: inv.WarehouseID,
+					Type:        "adjustment",
+					Quantity:    inv.Quantity,
+					Balance:     inv.Quantity,
+					Note:        "opening balance",
+				}
+				if err := tx.Create(&opening).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&stockMovement0012{})
+		},
+	})
+}
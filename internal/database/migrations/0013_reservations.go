@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// inventory0013 is the column this migration adds to inventories.
+type inventory0013 struct {
+	Reserved int
+}
+
+func (inventory0013) TableName() string { return "inventories" }
+
+type reservation0013 struct {
+	Base
+	ItemID      uuid.UUID `gorm:"index:idx_reservations_stock"`
+	WarehouseID uuid.UUID `gorm:"index:idx_reservations_stock"`
+	Quantity    int
+	Status      string    `gorm:"index"`
+	ExpiresAt   time.Time `gorm:"index"`
+	UserID      uuid.UUID
+	OrderID     *uuid.UUID
+	Note        string
+}
+
+func (reservation0013) TableName() string { return "reservations" }
+
+func init() {
+	register(Migration{
+		Version: "0013",
+		Name:    "reservations",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&inventory0013{}, &reservation0013{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&reservation0013{}); err != nil {
+				return err
+			}
+			return dropColumns(tx, &inventory0013{}, "Reserved")
+		},
+	})
+}
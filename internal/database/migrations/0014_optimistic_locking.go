@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// inventory0014 is the stock table as this migration leaves it: versioned,
+// and with at most one record per item and warehouse.
+type inventory0014 struct {
+	Base
+	Version     int       `gorm:"not null;default:1"`
+	ItemID      uuid.UUID `gorm:"uniqueIndex:idx_inventories_stock"`
+	WarehouseID uuid.UUID `gorm:"uniqueIndex:idx_inventories_stock"`
+	Quantity    int
+	Reserved    int
+}
+
+func (inventory0014) TableName() string { return "inventories" }
+
+// item0014 and purchaseOrder0014 are the lock columns this migration adds.
+type item0014 struct {
+	Version int `gorm:"not null;default:1"`
+}
+
+func (item0014) TableName() string { return "items" }
+
+type purchaseOrder0014 struct {
+	Version int `gorm:"not null;default:1"`
+}
+
+func (purchaseOrder0014) TableName() string { return "purchase_orders" }
+
+func init() {
+	register(Migration{
+		Version: "0014",
+		Name:    "optimistic_locking",
+		Up: func(tx *gorm.DB) error {
+			if err := mergeDuplicateInventory(tx); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&item0014{}, &purchaseOrder0014{}, &inventory0014{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&inventory0014{}, "idx_inventories_stock"); err != nil {
+				return err
+			}
+			for _, model := range []interface{}{&item0014{}, &purchaseOrder0014{}, &inventory0014{}} {
+				if err := dropColumns(tx, model, "Version"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// mergeDuplicateInventory folds every stock record for an item and warehouse
+// into one, so the unique index can be built. The oldest live record keeps
+// the combined quantities; the ledger is per item and warehouse already.
+func mergeDuplicateInventory(tx *gorm.DB) error {
+	var pairs []struct {
+		ItemID      string
+		WarehouseID string
+	}
+	err := tx.Model(&inventory0014{}).Unscoped().
+		Select("item_id, warehouse_id").
+		Group("item_id, warehouse_id").
+		Having("COUNT(*) > 1").
+		Scan(&pairs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		var records []inventory0014
+		err := tx.Unscoped().Where("item_id = ? AND warehouse_id = ?", pair.ItemID, pair.WarehouseID).
+			Order("deleted_at IS NOT NULL, created_at").
+			Find(&records).Error
+		if err != nil {
+			return err
+		}
+
+		keep := records[0]
+		for _, dup := range records[1:] {
+			if !dup.DeletedAt.Valid {
+				keep.Quantity += dup.Quantity
+				keep.Reserved += dup.Reserved
+			}
+			if err := tx.Unscoped().Delete(&dup).Error; err != nil {
+				return err
+			}
+		}
+		err = tx.Unscoped().Model(&keep).Select("quantity", "reserved").
+			Updates(map[string]interface{}{"quantity": keep.Quantity, "reserved": keep.Reserved}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
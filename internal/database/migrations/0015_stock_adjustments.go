@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type stockAdjustment0015 struct {
+	Base
+	InventoryID     uuid.UUID `gorm:"index"`
+	ItemID          uuid.UUID
+	WarehouseID     uuid.UUID `gorm:"index"`
+	Delta           int
+	CountedQuantity *int
+	Reason          string `gorm:"index"`
+	Note            string
+	Status          string `gorm:"index"`
+	RequestedBy     uuid.UUID
+	DecidedBy       *uuid.UUID
+	DecidedAt       *time.Time
+}
+
+func (stockAdjustment0015) TableName() string { return "stock_adjustments" }
+
+func init() {
+	register(Migration{
+		Version: "0015",
+		Name:    "stock_adjustments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&stockAdjustment0015{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&stockAdjustment0015{})
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0016 is the column this migration adds to users. Existing emails were
+// never confirmed, so they start out unverified.
+type user0016 struct {
+	EmailVerifiedAt *time.Time
+}
+
+func (user0016) TableName() string { return "users" }
+
+func init() {
+	register(Migration{
+		Version: "0016",
+		Name:    "email_verified",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&user0016{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &user0016{}, "EmailVerifiedAt")
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type adminBootstrap0017 struct {
+	ID        int `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+func (adminBootstrap0017) TableName() string { return "admin_bootstraps" }
+
+func init() {
+	register(Migration{
+		Version: "0017",
+		Name:    "admin_bootstrap",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&adminBootstrap0017{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&adminBootstrap0017{})
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// stockAdjustment0018 is the column this migration adds to stock
+// adjustments. Adjustments requested before it have no version, which is
+// not checked.
+type stockAdjustment0018 struct {
+	InventoryVersion int
+}
+
+func (stockAdjustment0018) TableName() string { return "stock_adjustments" }
+
+func init() {
+	register(Migration{
+		Version: "0018",
+		Name:    "adjustment_inventory_version",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&stockAdjustment0018{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &stockAdjustment0018{}, "InventoryVersion")
+		},
+	})
+}
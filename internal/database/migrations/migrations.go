@@ -0,0 +1,208 @@
+// Package migrations contains the versioned schema migrations for the
+// inventory database and the runner that applies them.
+//
+// Each migration lives in its own file named after its version and registers
+// itself from init. Versions are applied in ascending order and recorded in
+// the schema_migrations table, so a migration runs at most once per database.
+//
+// Migrations never use the models package. Each one declares the tables and
+// columns it touches as they were when it was written, in structs named after
+// the model and the migration version (user0005 is the users columns added by
+// 0005), so that a migration builds the same schema however the models change
+// later, and every change to the schema happens in exactly one migration.
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a single, versioned schema change.
+type Migration struct {
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey" json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes whether a known migration has been applied.
+type Status struct {
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var registry []Migration
+
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// All returns every registered migration ordered by version.
+func All() []Migration {
+	all := make([]Migration, len(registry))
+	copy(all, registry)
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all
+}
+
+func applied(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]SchemaMigration, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
+
+// Up applies all pending migrations in order and returns the versions applied.
+func Up(db *gorm.DB) ([]string, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, m := range All() {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return versions, fmt.Errorf("migration %s_%s failed: %w", m.Version, m.Name, err)
+		}
+		versions = append(versions, m.Version)
+	}
+
+	return versions, nil
+}
+
+// Down rolls back the most recently applied migrations, up to steps of them,
+// and returns the versions rolled back.
+func Down(db *gorm.DB, steps int) ([]string, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	all := All()
+	var versions []string
+	for i := len(all) - 1; i >= 0 && len(versions) < steps; i-- {
+		m := all[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if m.Down != nil {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return versions, fmt.Errorf("rollback %s_%s failed: %w", m.Version, m.Name, err)
+		}
+		versions = append(versions, m.Version)
+	}
+
+	return versions, nil
+}
+
+// List reports every known migration and whether it has been applied.
+func List(db *gorm.DB) ([]Status, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, m := range All() {
+		status := Status{Version: m.Version, Name: m.Name}
+		if row, ok := done[m.Version]; ok {
+			status.Applied = true
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// keepIndexes runs change against model's table, then restores any of the
+// table's indexes that change lost. SQLite cannot alter a column or
+// constraint in place, so GORM rebuilds the table, which drops its indexes.
+func keepIndexes(tx *gorm.DB, model interface{}, change func() error) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return change()
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	var indexes []struct {
+		Name string
+		SQL  string
+	}
+	err := tx.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", stmt.Table).
+		Scan(&indexes).Error
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if tx.Migrator().HasIndex(model, index.Name) {
+			continue
+		}
+		if err := tx.Exec(index.SQL).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropColumns drops columns from model's table, keeping its other indexes.
+func dropColumns(tx *gorm.DB, model interface{}, columns ...string) error {
+	return keepIndexes(tx, model, func() error {
+		for _, column := range columns {
+			if err := tx.Migrator().DropColumn(model, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
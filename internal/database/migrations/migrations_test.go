@@ -0,0 +1,203 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// openBaselineDB returns a database as the release before versioned
+// migrations left it.
+func openBaselineDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openTestDB(t)
+	schema, err := os.ReadFile("testdata/baseline_schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(string(schema)).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func mustExec(t *testing.T, db *gorm.DB, sql string, values ...interface{}) {
+	t.Helper()
+	if err := db.Exec(sql, values...).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// schemaObjects lists the tables and indexes in db.
+func schemaObjects(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var names []string
+	err := db.Raw("SELECT type || ' ' || name FROM sqlite_master WHERE type IN ('table', 'index') AND name NOT LIKE 'sqlite_%' ORDER BY 1").
+		Scan(&names).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func freshSchema(t *testing.T) []string {
+	t.Helper()
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return schemaObjects(t, db)
+}
+
+func TestUpFromBaseline(t *testing.T) {
+	db := openBaselineDB(t)
+	mustExec(t, db, "INSERT INTO roles (id, name) VALUES (?, ?)", "6f1b9a52-3c57-4a43-9a57-2b1f1f0c0a01", "manager")
+	mustExec(t, db, "INSERT INTO users (id, username, password, role_id) VALUES (?, ?, ?, ?)",
+		"6f1b9a52-3c57-4a43-9a57-2b1f1f0c0b01", "alice", "hash", "6f1b9a52-3c57-4a43-9a57-2b1f1f0c0a01")
+
+	versions, err := Up(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != len(All()) {
+		t.Fatalf("applied %d migrations, want %d", len(versions), len(All()))
+	}
+
+	if db.Migrator().HasColumn(&legacyUser{}, "RoleID") {
+		t.Error("users.role_id was not dropped")
+	}
+	var roleID string
+	err = db.Raw("SELECT role_id FROM user_roles WHERE user_id = ?", "6f1b9a52-3c57-4a43-9a57-2b1f1f0c0b01").Scan(&roleID).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roleID != "6f1b9a52-3c57-4a43-9a57-2b1f1f0c0a01" {
+		t.Errorf("user_roles role_id = %q, want the user's old role", roleID)
+	}
+
+	var granted int64
+	err = db.Raw(`SELECT COUNT(*) FROM role_permissions
+		JOIN permissions ON permissions.id = role_permissions.permission_id
+		WHERE role_permissions.role_id = ? AND permissions.resource = ? AND permissions.action = ?`,
+		"6f1b9a52-3c57-4a43-9a57-2b1f1f0c0a01", "all_warehouses", "access").Scan(&granted).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if granted != 1 {
+		t.Error("existing role was not granted all_warehouses:access, so warehouse scoping locks it out")
+	}
+
+	if got, want := schemaObjects(t, db), freshSchema(t); !slices.Equal(got, want) {
+		t.Errorf("upgraded schema differs from a fresh one:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestDownAndUpAgain(t *testing.T) {
+	want := freshSchema(t)
+	for steps := 1; steps < len(All()); steps++ {
+		db := openTestDB(t)
+		if _, err := Up(db); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Down(db, steps); err != nil {
+			t.Fatalf("rolling back %d: %v", steps, err)
+		}
+		if _, err := Up(db); err != nil {
+			t.Fatalf("reapplying %d: %v", steps, err)
+		}
+		if got := schemaObjects(t, db); !slices.Equal(got, want) {
+			t.Errorf("schema after rolling back and reapplying %d migrations differs:\n got %v\nwant %v", steps, got, want)
+		}
+	}
+}
+
+func TestDownAll(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := Down(db, len(All()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != len(All()) {
+		t.Fatalf("rolled back %d migrations, want %d", len(versions), len(All()))
+	}
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0] != "schema_migrations" {
+		t.Errorf("tables left after rolling back everything: %v", tables)
+	}
+
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpMergesDuplicateInventory(t *testing.T) {
+	const item, warehouse = "0b7c2f8e-5d1a-4c3b-9e6f-1a2b3c4d5e01", "0b7c2f8e-5d1a-4c3b-9e6f-1a2b3c4d5e02"
+	db := openBaselineDB(t)
+	rows := []struct {
+		id        string
+		createdAt string
+		quantity  int
+		deletedAt interface{}
+	}{
+		{"0b7c2f8e-5d1a-4c3b-9e6f-1a2b3c4d5e11", "2024-01-02 00:00:00", 7, nil},
+		{"0b7c2f8e-5d1a-4c3b-9e6f-1a2b3c4d5e12", "2024-01-01 00:00:00", 5, nil},
+		{"0b7c2f8e-5d1a-4c3b-9e6f-1a2b3c4d5e13", "2023-12-01 00:00:00", 3, "2024-01-03 00:00:00"},
+	}
+	for _, row := range rows {
+		mustExec(t, db, "INSERT INTO inventories (id, created_at, updated_at, deleted_at, item_id, warehouse_id, quantity) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			row.id, row.createdAt, row.createdAt, row.deletedAt, item, warehouse, row.quantity)
+	}
+
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var stock []struct {
+		ID       string
+		Quantity int
+	}
+	if err := db.Raw("SELECT id, quantity FROM inventories").Scan(&stock).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stock) != 1 {
+		t.Fatalf("%d stock records left, want the duplicates merged into one", len(stock))
+	}
+	if stock[0].ID != rows[1].id || stock[0].Quantity != 12 {
+		t.Errorf("kept %s with %d, want the oldest live record with the live quantities, 12", stock[0].ID, stock[0].Quantity)
+	}
+
+	var ledger int
+	if err := db.Raw("SELECT SUM(quantity) FROM stock_movements WHERE item_id = ? AND warehouse_id = ?", item, warehouse).Scan(&ledger).Error; err != nil {
+		t.Fatal(err)
+	}
+	if ledger != 12 {
+		t.Errorf("ledger holds %d, want it to balance with the merged stock", ledger)
+	}
+
+	err := db.Exec("INSERT INTO inventories (id, item_id, warehouse_id, quantity) VALUES (?, ?, ?, ?)",
+		"0b7c2f8e-5d1a-4c3b-9e6f-1a2b3c4d5e14", item, warehouse, 1).Error
+	if err == nil {
+		t.Error("a second stock record for the same item and warehouse was accepted")
+	}
+}
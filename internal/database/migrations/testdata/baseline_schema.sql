@@ -0,0 +1,39 @@
+-- The schema of a database created by AutoMigrate on boot, before versioned
+-- migrations existed. Upgrade tests start from it.
+CREATE TABLE `role_permissions` (`role_id` uuid,`permission_id` uuid,PRIMARY KEY (`role_id`,`permission_id`),CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`));
+CREATE TABLE `items` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`description` text,`price` real,`category_id` text,`supplier_id` text,`viewer_count` integer,`favorite_count` integer,PRIMARY KEY (`id`));
+CREATE INDEX `idx_items_deleted_at` ON `items`(`deleted_at`);
+CREATE TABLE `roles` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`description` text,PRIMARY KEY (`id`),CONSTRAINT `uni_roles_name` UNIQUE (`name`));
+CREATE INDEX `idx_roles_deleted_at` ON `roles`(`deleted_at`);
+CREATE TABLE `users` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`username` text,`password` text,`role_id` uuid,PRIMARY KEY (`id`),CONSTRAINT `fk_users_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),CONSTRAINT `uni_users_username` UNIQUE (`username`));
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE TABLE `warehouses` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`location` text,`capacity` integer,PRIMARY KEY (`id`));
+CREATE INDEX `idx_warehouses_deleted_at` ON `warehouses`(`deleted_at`);
+CREATE TABLE `suppliers` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`contact_info` text,`address` text,PRIMARY KEY (`id`));
+CREATE INDEX `idx_suppliers_deleted_at` ON `suppliers`(`deleted_at`);
+CREATE TABLE `discounts` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`percentage` real,`start_date` datetime,`end_date` datetime,`active` numeric,PRIMARY KEY (`id`));
+CREATE INDEX `idx_discounts_deleted_at` ON `discounts`(`deleted_at`);
+CREATE TABLE `media` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`item_id` uuid,`type` text,`url` text,`public_id` text,PRIMARY KEY (`id`),CONSTRAINT `fk_items_media` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`));
+CREATE INDEX `idx_media_deleted_at` ON `media`(`deleted_at`);
+CREATE TABLE `variants` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`item_id` uuid,`name` text,PRIMARY KEY (`id`),CONSTRAINT `fk_items_variants` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`));
+CREATE INDEX `idx_variants_deleted_at` ON `variants`(`deleted_at`);
+CREATE TABLE `options` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`variant_id` uuid,`name` text,PRIMARY KEY (`id`),CONSTRAINT `fk_variants_options` FOREIGN KEY (`variant_id`) REFERENCES `variants`(`id`));
+CREATE INDEX `idx_options_deleted_at` ON `options`(`deleted_at`);
+CREATE TABLE `reviews` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`user_id` text,`item_id` uuid,`rating` integer,`comment` text,PRIMARY KEY (`id`),CONSTRAINT `fk_items_reviews` FOREIGN KEY (`item_id`) REFERENCES `items`(`id`));
+CREATE INDEX `idx_reviews_deleted_at` ON `reviews`(`deleted_at`);
+CREATE TABLE `favorites` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`user_id` text,`item_id` text,PRIMARY KEY (`id`));
+CREATE INDEX `idx_favorites_deleted_at` ON `favorites`(`deleted_at`);
+CREATE TABLE `inventories` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`item_id` text,`warehouse_id` text,`quantity` integer,PRIMARY KEY (`id`));
+CREATE INDEX `idx_inventories_deleted_at` ON `inventories`(`deleted_at`);
+CREATE TABLE `categories` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`description` text,PRIMARY KEY (`id`));
+CREATE INDEX `idx_categories_deleted_at` ON `categories`(`deleted_at`);
+CREATE TABLE `purchase_orders` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`supplier_id` text,`warehouse_id` text,`status` text,`total_amount` real,`date` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_purchase_orders_deleted_at` ON `purchase_orders`(`deleted_at`);
+CREATE TABLE `purchase_order_items` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`purchase_order_id` uuid,`item_id` text,`quantity` integer,`unit_price` real,PRIMARY KEY (`id`),CONSTRAINT `fk_purchase_orders_items` FOREIGN KEY (`purchase_order_id`) REFERENCES `purchase_orders`(`id`));
+CREATE INDEX `idx_purchase_order_items_deleted_at` ON `purchase_order_items`(`deleted_at`);
+CREATE TABLE `orders` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`user_id` text,`warehouse_id` text,`total_amount` real,`status` text,`payment_method` text,`date` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_orders_deleted_at` ON `orders`(`deleted_at`);
+CREATE TABLE `order_items` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`order_id` uuid,`item_id` text,`quantity` integer,`unit_price` real,PRIMARY KEY (`id`),CONSTRAINT `fk_orders_items` FOREIGN KEY (`order_id`) REFERENCES `orders`(`id`));
+CREATE INDEX `idx_order_items_deleted_at` ON `order_items`(`deleted_at`);
+CREATE TABLE `permissions` (`id` uuid,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`resource` text,`action` text,PRIMARY KEY (`id`));
+CREATE INDEX `idx_permissions_deleted_at` ON `permissions`(`deleted_at`);
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"go-rest/internal/models"
+	"go-rest/internal/services"
+	"go-rest/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AdjustmentHandler serves manual stock adjustments.
+type AdjustmentHandler struct {
+	DB          *gorm.DB
+	Adjustments *services.AdjustmentService
+}
+
+func NewAdjustmentHandler(db *gorm.DB, adjustments *services.AdjustmentService) *AdjustmentHandler {
+	return &AdjustmentHandler{DB: db, Adjustments: adjustments}
+}
+
+// adjustmentStatus is 202 for an adjustment waiting for approval and
+// 201 for one already applied.
+func adjustmentStatus(adjustment *models.StockAdjustment) int {
+	if adjustment.Status == services.AdjustmentPending {
+		return http.StatusAccepted
+	}
+	return http.StatusCreated
+}
+
+// CreateAdjustment godoc
+// @Summary      Adjust stock
+// @Description  Adjust a stock record by a signed delta, or to a counted_quantity, giving a reason code and optional note. Adjustments larger than the approval threshold stay pending (202) until approved; smaller ones are applied (201). Send the version you read to be refused with 409 if the record has changed since.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Adjustment Input"
+// @Success      201    {object}  models.StockAdjustment
+// @Success      202    {object}  models.StockAdjustment
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/adjustments [post]
+func (h *AdjustmentHandler) CreateAdjustment(c *gin.Context) {
+	var input struct {
+		InventoryID     string `json:"inventory_id"`
+		Delta           *int   `json:"delta"`
+		CountedQuantity *int   `json:"counted_quantity"`
+		Reason          string `json:"reason"`
+		Note            string `json:"note"`
+		Version         int    `json:"version"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inventoryID, ok := parseUUID(c, "inventory_id", input.InventoryID)
+	if !ok {
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	adjustment, err := h.Adjustments.WithContext(c).WithScope(warehouseScope(c)).Request(inventoryID, services.AdjustmentInput{
+		Delta:           input.Delta,
+		CountedQuantity: input.CountedQuantity,
+		Reason:          input.Reason,
+		Note:            input.Note,
+		Version:         input.Version,
+	}, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(adjustmentStatus(adjustment), adjustment)
+}
+
+// UpdateInventory godoc
+// @Summary      Update inventory
+// @Description  Set a stock record to a counted quantity. This is a stock adjustment with counted_quantity: a reason code is required, and large changes stay pending (202) until approved.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "Inventory ID"
+// @Param        input  body      object  true  "Quantity Input"
+// @Success      201    {object}  models.StockAdjustment
+// @Success      202    {object}  models.StockAdjustment
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/{id} [put]
+func (h *AdjustmentHandler) UpdateInventory(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Quantity int    `json:"quantity"`
+		Reason   string `json:"reason"`
+		Version  int    `json:"version"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	adjustment, err := h.Adjustments.WithContext(c).WithScope(warehouseScope(c)).Request(id, services.AdjustmentInput{
+		CountedQuantity: &input.Quantity,
+		Reason:          input.Reason,
+		Note:            input.Note,
+		Version:         input.Version,
+	}, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(adjustmentStatus(adjustment), adjustment)
+}
+
+// GetAdjustments godoc
+// @Summary      List stock adjustments
+// @Description  Get stock adjustments, newest first, limited to your warehouses
+// @Tags         inventory
+// @Produce      json
+// @Param        inventory_id  query     string  false  "Inventory ID"
+// @Param        item_id       query     string  false  "Item ID"
+// @Param        warehouse_id  query     string  false  "Warehouse ID"
+// @Param        status        query     string  false  "pending, applied or rejected"
+// @Param        reason        query     string  false  "Reason code"
+// @Param        page          query     int     false  "Page number"
+// @Param        page_size     query     int     false  "Page size"
+// @Success      200           {array}   models.StockAdjustment
+// @Failure      500           {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/adjustments [get]
+func (h *AdjustmentHandler) GetAdjustments(c *gin.Context) {
+	var adjustments []models.StockAdjustment
+	query := h.DB.WithContext(c).Model(&models.StockAdjustment{}).Scopes(warehouseScope(c).Filter("warehouse_id"))
+
+	for _, column := range []string{"inventory_id", "item_id", "warehouse_id", "status", "reason"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	query = query.Order("created_at desc").Scopes(utils.Paginate(c))
+
+	if err := query.Find(&adjustments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustments)
+}
+
+// GetAdjustmentReasons godoc
+// @Summary      List adjustment reasons
+// @Description  Get the reason codes a stock adjustment may give, and the largest change applied without approval
+// @Tags         inventory
+// @Produce      json
+// @Success      200  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/adjustments/reasons [get]
+func (h *AdjustmentHandler) GetAdjustmentReasons(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"reasons":            h.Adjustments.Config.Reasons,
+		"approval_threshold": h.Adjustments.Config.ApprovalThreshold,
+	})
+}
+
+// GetAdjustment godoc
+// @Summary      Get a stock adjustment
+// @Description  Get a stock adjustment by ID
+// @Tags         inventory
+// @Produce      json
+// @Param        id   path      string  true  "Adjustment ID"
+// @Success      200  {object}  models.StockAdjustment
+// @Failure      400  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/adjustments/{id} [get]
+func (h *AdjustmentHandler) GetAdjustment(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	adjustment, err := h.Adjustments.WithContext(c).WithScope(warehouseScope(c)).Get(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// ApproveAdjustment godoc
+// @Summary      Approve a stock adjustment
+// @Description  Apply a pending stock adjustment. Someone other than the requester has to approve it. A count is refused with 409 if the stock has moved since it was counted.
+// @Tags         inventory
+// @Produce      json
+// @Param        id   path      string  true  "Adjustment ID"
+// @Success      200  {object}  models.StockAdjustment
+// @Failure      400  {object}  gin.H
+// @Failure      403  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/adjustments/{id}/approve [post]
+func (h *AdjustmentHandler) ApproveAdjustment(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	adjustment, err := h.Adjustments.WithContext(c).WithScope(warehouseScope(c)).Approve(id, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// RejectAdjustment godoc
+// @Summary      Reject a stock adjustment
+// @Description  Close a pending stock adjustment without changing stock
+// @Tags         inventory
+// @Produce      json
+// @Param        id   path      string  true  "Adjustment ID"
+// @Success      200  {object}  models.StockAdjustment
+// @Failure      400  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/adjustments/{id}/reject [post]
+func (h *AdjustmentHandler) RejectAdjustment(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	adjustment, err := h.Adjustments.WithContext(c).WithScope(warehouseScope(c)).Reject(id, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
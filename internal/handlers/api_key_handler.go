@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"go-rest/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler serves personal API keys.
+type APIKeyHandler struct {
+	Keys *services.APIKeyService
+}
+
+func NewAPIKeyHandler(keys *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{Keys: keys}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Create a personal API key limited to a subset of your permissions. The key is only returned once.
+// @Tags         api_keys
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Name, scopes (resource:action) and optional expires_at"
+// @Success      201    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	// Keys must be created by a person, not by another key
+	if _, isKey := c.Get("apiKeyID"); isKey {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot create API keys"})
+		return
+	}
+
+	var input struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required,min=1"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	held, _ := c.Get("permissions")
+	perms, _ := held.(map[string]bool)
+
+	raw, key, err := h.Keys.WithContext(c).Create(userID, input.Name, input.Scopes, input.ExpiresAt, perms)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownPermission) || errors.Is(err, services.ErrScopeNotHeld) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"key": raw, "api_key": key})
+}
+
+// GetAPIKeys godoc
+// @Summary      List API keys
+// @Description  List your API keys. Key values are never returned.
+// @Tags         api_keys
+// @Produce      json
+// @Success      200  {array}   go-rest_internal_models.APIKey
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.Keys.WithContext(c).List(c.MustGet("userID").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Revoke one of your API keys
+// @Tags         api_keys
+// @Produce      json
+// @Param        id   path      string  true  "API Key ID"
+// @Success      200  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.Keys.WithContext(c).Revoke(c.MustGet("userID").(uuid.UUID), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"go-rest/internal/models"
+	"go-rest/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuditHandler serves the audit log of data changes.
+type AuditHandler struct {
+	DB *gorm.DB
+}
+
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{DB: db}
+}
+
+// GetAuditLogs godoc
+// @Summary      List audit log entries
+// @Description  Get creates, updates and deletes with their before and after values, newest first
+// @Tags         audit
+// @Produce      json
+// @Param        actor_id     query     string  false  "User who made the change"
+// @Param        action       query     string  false  "create, update or delete"
+// @Param        entity_type  query     string  false  "Table name, e.g. items"
+// @Param        entity_id    query     string  false  "Entity ID"
+// @Param        request_id   query     string  false  "Request ID"
+// @Param        from         query     string  false  "Earliest time (RFC 3339)"
+// @Param        to           query     string  false  "Latest time (RFC 3339)"
+// @Param        page         query     int     false  "Page number"
+// @Param        page_size    query     int     false  "Page size"
+// @Success      200  {array}   models.AuditLog
+// @Failure      400  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /audit [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	var entries []models.AuditLog
+	query := h.DB.WithContext(c).Model(&models.AuditLog{})
+
+	if actor := c.Query("actor_id"); actor != "" {
+		actorID, ok := parseUUID(c, "actor_id", actor)
+		if !ok {
+			return
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+	for _, column := range []string{"action", "entity_type", "entity_id", "request_id"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	for param, op := range map[string]string{"from": ">=", "to": "<="} {
+		if value := c.Query(param); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " format"})
+				return
+			}
+			query = query.Where("created_at "+op+" ?", at)
+		}
+	}
+
+	query = query.Order("created_at desc").Scopes(utils.Paginate(c))
+
+	if err := query.Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
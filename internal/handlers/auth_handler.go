@@ -1,47 +1,68 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
+	"errors"
+	"go-rest/internal/config"
 	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"net/http"
-	"os"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-// jwtSecret is removed as it's replaced by os.Getenv("JWT_SECRET")
+// AuthHandler serves registration, login and session management.
+type AuthHandler struct {
+	DB        *gorm.DB
+	Config    *config.Config
+	Sessions  *services.SessionService
+	Guard     *services.LoginGuard
+	Passwords *services.PasswordService
+	MFA       *services.MFAService
+	OIDC      *services.OIDCService
+	Users     *services.UserService
+}
+
+func NewAuthHandler(db *gorm.DB, cfg *config.Config, sessions *services.SessionService, guard *services.LoginGuard, passwords *services.PasswordService, mfa *services.MFAService, oidc *services.OIDCService, users *services.UserService) *AuthHandler {
+	return &AuthHandler{DB: db, Config: cfg, Sessions: sessions, Guard: guard, Passwords: passwords, MFA: mfa, OIDC: oidc, Users: users}
+}
 
 // Register godoc
 // @Summary      Register a new user
-// @Description  Register a new user with username and password
+// @Description  Register a new user with username and password. The first user becomes an admin; everyone else gets the default role. Disabled in invite-only mode.
 // @Tags         auth
 // @Accept       x-www-form-urlencoded
 // @Produce      json
 // @Param        username  formData  string  true  "Username"
 // @Param        password  formData  string  true  "Password"
+// @Param        email     formData  string  false  "Email, used for password resets"
 // @Success      201       {object}  models.User
 // @Failure      400       {object}  gin.H
+// @Failure      403       {object}  gin.H
+// @Failure      409       {object}  gin.H
 // @Failure      500       {object}  gin.H
 // @Router       /register [post]
-func Register(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBind(&user); err != nil {
+func (h *AuthHandler) Register(c *gin.Context) {
+	var input struct {
+		Username string `form:"username" json:"username" binding:"required"`
+		Password string `form:"password" json:"password" binding:"required"`
+		Email    string `form:"email" json:"email" binding:"omitempty,email"`
+	}
+	if err := c.ShouldBind(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	user, err := h.Users.WithContext(c).Register(services.NewUser{
+		Username: input.Username,
+		Password: input.Password,
+		Email:    input.Email,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
-	}
-	user.Password = string(hashedPassword)
-
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -50,7 +71,7 @@ func Register(c *gin.Context) {
 
 // Login godoc
 // @Summary      Login
-// @Description  Login with username and password to get JWT token
+// @Description  Login with username and password to get an access token and a refresh token. Users with MFA enabled get an mfa_token instead, to be exchanged at /login/mfa.
 // @Tags         auth
 // @Accept       x-www-form-urlencoded
 // @Produce      json
@@ -59,37 +80,414 @@ func Register(c *gin.Context) {
 // @Success      200       {object}  gin.H
 // @Failure      400       {object}  gin.H
 // @Failure      401       {object}  gin.H
+// @Failure      403       {object}  gin.H
+// @Failure      429       {object}  gin.H
 // @Failure      500       {object}  gin.H
 // @Router       /login [post]
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	var input models.User
 	if err := c.ShouldBind(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	ip := c.ClientIP()
+	if err := h.Guard.Check(input.Username, ip); err != nil {
+		tooManyAttempts(c, err)
+		return
+	}
+
 	var user models.User
-	if err := database.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("username = ?", input.Username).First(&user).Error; err != nil {
+		if err := h.Guard.Failure(input.Username, ip, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		if err := h.Guard.Failure(input.Username, ip, &user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
 
-	// Generate JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+	// The password alone is not enough; failures are counted at the
+	// second step.
+	if user.MFAEnabled {
+		challenge, err := h.MFA.Challenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": challenge})
+		return
+	}
+	h.Guard.Success(user.Username, ip, user.ID)
+
+	pair, err := h.Sessions.Start(user.ID, c.Request.UserAgent(), ip, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// LoginMFA godoc
+// @Summary      Complete an MFA login
+// @Description  Exchange the mfa_token from /login and a TOTP or recovery code for an access token and a refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "MFA token and code"
+// @Success      200    {object}  services.TokenPair
+// @Failure      400    {object}  gin.H
+// @Failure      401    {object}  gin.H
+// @Failure      429    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Router       /login/mfa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var input struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := h.MFA.ParseChallenge(input.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	var user models.User
+	if err := h.DB.WithContext(c).First(&user, "id = ?", userID).Error; err != nil || user.Disabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := h.Guard.Check(user.Username, ip); err != nil {
+		tooManyAttempts(c, err)
+		return
+	}
+
+	if err := h.MFA.Verify(&user, input.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidMFACode) || errors.Is(err, services.ErrMFANotEnabled) {
+			if err := h.Guard.Failure(user.Username, ip, &user.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			h.MFA.Events.Record(services.EventMFAFailed, &user.ID, user.Username, ip, "")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid MFA code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.Guard.Success(user.Username, ip, user.ID)
+
+	pair, err := h.Sessions.Start(user.ID, c.Request.UserAgent(), ip, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// OIDCLogin godoc
+// @Summary      Start a single sign-on login
+// @Description  Redirect to the OpenID Connect provider. The provider redirects back to /auth/oidc/callback.
+// @Tags         auth
+// @Success      302
+// @Failure      404  {object}  gin.H
+// @Failure      502  {object}  gin.H
+// @Router       /auth/oidc/login [get]
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	target, err := h.OIDC.AuthURL(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCDisabled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, target)
+}
+
+// OIDCCallback godoc
+// @Summary      Complete a single sign-on login
+// @Description  Exchange the provider's authorization code for an access token and a refresh token. The user is created or linked on first login and their role follows their IdP groups. Users with local MFA whose provider did not report a second factor get an mfa_token, as with /login.
+// @Tags         auth
+// @Produce      json
+// @Param        code   query     string  true  "Authorization code"
+// @Param        state  query     string  true  "State"
+// @Success      200    {object}  services.TokenPair
+// @Failure      400    {object}  gin.H
+// @Failure      401    {object}  gin.H
+// @Failure      403    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      502    {object}  gin.H
+// @Router       /auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": providerErr + ": " + c.Query("error_description")})
+		return
+	}
+
+	user, identity, err := h.OIDC.Callback(c.Request.Context(), c.Query("code"), c.Query("state"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCDisabled):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token"})
+		case errors.Is(err, services.ErrLastAdmin):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
+
+	if user.MFAEnabled && !identity.MFA {
+		challenge, err := h.MFA.Challenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": challenge})
+		return
+	}
+
+	ip := c.ClientIP()
+	h.Guard.Success(user.Username, ip, user.ID)
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	pair, err := h.Sessions.Start(user.ID, c.Request.UserAgent(), ip, identity.MFA)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+	c.JSON(http.StatusOK, pair)
+}
+
+// RefreshToken godoc
+// @Summary      Refresh tokens
+// @Description  Exchange a refresh token for a new access and refresh token. Each refresh token can be used once; reusing one revokes the session.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Refresh token"
+// @Success      200    {object}  services.TokenPair
+// @Failure      400    {object}  gin.H
+// @Failure      401    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Router       /token/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.Sessions.Refresh(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrTokenReused) || errors.Is(err, services.ErrSessionRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke the current session and all of its tokens
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  gin.H
+// @Failure      400  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID, exists := c.Get("sessionID")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request is not authenticated by a session"})
+		return
+	}
+
+	if err := h.Sessions.Revoke(sessionID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Change the current user's password. Every other session is revoked. A wrong current password is throttled like a failed login.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Current and new password"
+// @Success      200    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      429    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me/password [put]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Requests made with an API key have no session to keep.
+	keep := uuid.Nil
+	if sessionID, exists := c.Get("sessionID"); exists {
+		keep = sessionID.(uuid.UUID)
+	}
+
+	// The current password is checked under the LoginGuard, so a stolen
+	// session cannot be used to guess it.
+	user := c.MustGet("user").(models.User)
+	ip := c.ClientIP()
+	if err := h.Guard.Check(user.Username, ip); err != nil {
+		tooManyAttempts(c, err)
+		return
+	}
+
+	err := h.Passwords.Change(user.ID, keep, input.CurrentPassword, input.NewPassword, ip)
+	if errors.Is(err, services.ErrWrongPassword) {
+		if err := h.Guard.Failure(user.Username, ip, &user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.Guard.Reset(user.Username)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Send a single-use reset link to the user registered with the email. The response is the same whether or not the email is known. Requests are throttled per email and address like failed logins.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Email"
+// @Success      202    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      429    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Router       /password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := h.Guard.ResetRequested(input.Email, ip); err != nil {
+		tooManyAttempts(c, err)
+		return
+	}
+	if err := h.Passwords.RequestReset(input.Email, ip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send reset link"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password with a reset token. The token can be used once and every session of the user is revoked.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Reset token and new password"
+// @Success      200    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Router       /password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Passwords.Reset(input.Token, input.Password, c.ClientIP()); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// JWKS publishes the public keys that verify tokens issued by this server.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Sessions.Tokens.JWKS())
+}
+
+// tooManyAttempts answers a LoginGuard error: 429 with Retry-After while
+// throttled, or 500 if the attempt could not be checked or counted.
+func tooManyAttempts(c *gin.Context, err error) {
+	var throttled *services.ThrottledError
+	if !errors.As(err, &throttled) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
 }
@@ -1,13 +1,22 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// CategoryHandler serves categories.
+type CategoryHandler struct {
+	DB *gorm.DB
+}
+
+func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{DB: db}
+}
+
 // CreateCategory godoc
 // @Summary      Create a category
 // @Description  Create a new product category
@@ -21,14 +30,14 @@ import (
 // @Failure      500          {object}  gin.H
 // @Security     BearerAuth
 // @Router       /categories [post]
-func CreateCategory(c *gin.Context) {
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	var category models.Category
 	if err := c.ShouldBind(&category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&category).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -45,9 +54,9 @@ func CreateCategory(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /categories [get]
-func GetCategories(c *gin.Context) {
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
 	var categories []models.Category
-	if err := database.DB.Find(&categories).Error; err != nil {
+	if err := h.DB.WithContext(c).Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -70,10 +79,10 @@ func GetCategories(c *gin.Context) {
 // @Failure      500          {object}  gin.H
 // @Security     BearerAuth
 // @Router       /categories/{id} [put]
-func UpdateCategory(c *gin.Context) {
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	id := c.Param("id")
 	var category models.Category
-	if err := database.DB.First(&category, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&category, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
@@ -87,7 +96,7 @@ func UpdateCategory(c *gin.Context) {
 	category.Name = input.Name
 	category.Description = input.Description
 
-	if err := database.DB.Save(&category).Error; err != nil {
+	if err := h.DB.WithContext(c).Save(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -106,15 +115,15 @@ func UpdateCategory(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /categories/{id} [delete]
-func DeleteCategory(c *gin.Context) {
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 	var category models.Category
-	if err := database.DB.First(&category, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&category, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&category).Error; err != nil {
+	if err := h.DB.WithContext(c).Delete(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
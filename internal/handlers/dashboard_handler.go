@@ -1,38 +1,50 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
+	"go-rest/internal/config"
 	"go-rest/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// DashboardHandler serves the dashboard summary.
+type DashboardHandler struct {
+	DB     *gorm.DB
+	Config *config.Config
+}
+
+func NewDashboardHandler(db *gorm.DB, cfg *config.Config) *DashboardHandler {
+	return &DashboardHandler{DB: db, Config: cfg}
+}
+
 // GetDashboardSummary godoc
 // @Summary      Get dashboard summary
-// @Description  Get counts of items, warehouses, users, suppliers, and low stock items
+// @Description  Get counts of items, warehouses, users, suppliers, and low stock items. Warehouses and low stock only count your warehouses.
 // @Tags         reports
 // @Produce      json
 // @Success      200  {object}  gin.H
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /reports/dashboard [get]
-func GetDashboardSummary(c *gin.Context) {
+func (h *DashboardHandler) GetDashboardSummary(c *gin.Context) {
 	var itemCount int64
-	database.DB.Model(&models.Item{}).Count(&itemCount)
+	h.DB.WithContext(c).Model(&models.Item{}).Count(&itemCount)
 
 	var warehouseCount int64
-	database.DB.Model(&models.Warehouse{}).Count(&warehouseCount)
+	h.DB.WithContext(c).Model(&models.Warehouse{}).Scopes(warehouseScope(c).Filter("id")).Count(&warehouseCount)
 
 	var userCount int64
-	database.DB.Model(&models.User{}).Count(&userCount)
+	h.DB.WithContext(c).Model(&models.User{}).Count(&userCount)
 
 	var supplierCount int64
-	database.DB.Model(&models.Supplier{}).Count(&supplierCount)
+	h.DB.WithContext(c).Model(&models.Supplier{}).Count(&supplierCount)
 
-	// Low stock items (e.g., < 10)
+	// Low stock items (below the configured threshold)
 	var lowStockCount int64
-	database.DB.Model(&models.Inventory{}).Where("quantity < ?", 10).Count(&lowStockCount)
+	h.DB.WithContext(c).Model(&models.Inventory{}).Scopes(warehouseScope(c).Filter("warehouse_id")).
+		Where("quantity < ?", h.Config.LowStockThreshold).Count(&lowStockCount)
 
 	c.JSON(http.StatusOK, gin.H{
 		"items":      itemCount,
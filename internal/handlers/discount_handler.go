@@ -1,13 +1,22 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// DiscountHandler serves discounts.
+type DiscountHandler struct {
+	DB *gorm.DB
+}
+
+func NewDiscountHandler(db *gorm.DB) *DiscountHandler {
+	return &DiscountHandler{DB: db}
+}
+
 // CreateDiscount godoc
 // @Summary      Create a discount
 // @Description  Create a new discount
@@ -24,14 +33,14 @@ import (
 // @Failure      500         {object}  gin.H
 // @Security     BearerAuth
 // @Router       /discounts [post]
-func CreateDiscount(c *gin.Context) {
+func (h *DiscountHandler) CreateDiscount(c *gin.Context) {
 	var discount models.Discount
 	if err := c.ShouldBind(&discount); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&discount).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&discount).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -48,9 +57,9 @@ func CreateDiscount(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /discounts [get]
-func GetDiscounts(c *gin.Context) {
+func (h *DiscountHandler) GetDiscounts(c *gin.Context) {
 	var discounts []models.Discount
-	if err := database.DB.Find(&discounts).Error; err != nil {
+	if err := h.DB.WithContext(c).Find(&discounts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -76,10 +85,10 @@ func GetDiscounts(c *gin.Context) {
 // @Failure      500         {object}  gin.H
 // @Security     BearerAuth
 // @Router       /discounts/{id} [put]
-func UpdateDiscount(c *gin.Context) {
+func (h *DiscountHandler) UpdateDiscount(c *gin.Context) {
 	id := c.Param("id")
 	var discount models.Discount
-	if err := database.DB.First(&discount, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&discount, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Discount not found"})
 		return
 	}
@@ -96,7 +105,7 @@ func UpdateDiscount(c *gin.Context) {
 	discount.EndDate = input.EndDate
 	discount.Active = input.Active
 
-	if err := database.DB.Save(&discount).Error; err != nil {
+	if err := h.DB.WithContext(c).Save(&discount).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -115,15 +124,15 @@ func UpdateDiscount(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /discounts/{id} [delete]
-func DeleteDiscount(c *gin.Context) {
+func (h *DiscountHandler) DeleteDiscount(c *gin.Context) {
 	id := c.Param("id")
 	var discount models.Discount
-	if err := database.DB.First(&discount, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&discount, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Discount not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&discount).Error; err != nil {
+	if err := h.DB.WithContext(c).Delete(&discount).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
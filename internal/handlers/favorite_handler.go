@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"net/http"
 
@@ -10,7 +9,16 @@ import (
 	"gorm.io/gorm"
 )
 
-func ToggleFavorite(c *gin.Context) {
+// FavoriteHandler serves favorites.
+type FavoriteHandler struct {
+	DB *gorm.DB
+}
+
+func NewFavoriteHandler(db *gorm.DB) *FavoriteHandler {
+	return &FavoriteHandler{DB: db}
+}
+
+func (h *FavoriteHandler) ToggleFavorite(c *gin.Context) {
 	itemID := c.Param("id")
 
 	userID, exists := c.Get("userID")
@@ -23,24 +31,24 @@ func ToggleFavorite(c *gin.Context) {
 	iid := uuid.MustParse(itemID)
 
 	var favorite models.Favorite
-	if err := database.DB.Where("user_id = ? AND item_id = ?", uid, iid).First(&favorite).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("user_id = ? AND item_id = ?", uid, iid).First(&favorite).Error; err != nil {
 		// Not favorited, so create it
 		newFav := models.Favorite{
 			UserID: uid,
 			ItemID: iid,
 		}
-		database.DB.Create(&newFav)
+		h.DB.WithContext(c).Create(&newFav)
 
 		// Increment count
-		database.DB.Model(&models.Item{}).Where("id = ?", iid).UpdateColumn("favorite_count", gorm.Expr("favorite_count + ?", 1))
+		h.DB.WithContext(c).Model(&models.Item{}).Where("id = ?", iid).UpdateColumn("favorite_count", gorm.Expr("favorite_count + ?", 1))
 
 		c.JSON(http.StatusCreated, gin.H{"message": "Favorited"})
 	} else {
 		// Favorited, so delete it
-		database.DB.Delete(&favorite)
+		h.DB.WithContext(c).Delete(&favorite)
 
 		// Decrement count
-		database.DB.Model(&models.Item{}).Where("id = ?", iid).UpdateColumn("favorite_count", gorm.Expr("favorite_count - ?", 1))
+		h.DB.WithContext(c).Model(&models.Item{}).Where("id = ?", iid).UpdateColumn("favorite_count", gorm.Expr("favorite_count - ?", 1))
 
 		c.JSON(http.StatusOK, gin.H{"message": "Unfavorited"})
 	}
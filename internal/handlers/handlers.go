@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"go-rest/internal/config"
+	"go-rest/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Handlers groups every HTTP handler so routes can be wired from a single
+// set of dependencies.
+type Handlers struct {
+	Auth          *AuthHandler
+	APIKey        *APIKeyHandler
+	Security      *SecurityHandler
+	Audit         *AuditHandler
+	MFA           *MFAHandler
+	User          *UserHandler
+	Role          *RoleHandler
+	Item          *ItemHandler
+	Category      *CategoryHandler
+	Warehouse     *WarehouseHandler
+	Supplier      *SupplierHandler
+	Discount      *DiscountHandler
+	Inventory     *InventoryHandler
+	Adjustment    *AdjustmentHandler
+	PurchaseOrder *PurchaseOrderHandler
+	Order         *OrderHandler
+	Report        *ReportHandler
+	Dashboard     *DashboardHandler
+	Media         *MediaHandler
+	Review        *ReviewHandler
+	Favorite      *FavoriteHandler
+}
+
+func New(db *gorm.DB, cfg *config.Config, sessions *services.SessionService, apiKeys *services.APIKeyService, permissions *services.PermissionCache, uploader *services.Cloudinary, notifier services.Notifier) *Handlers {
+	inventory := services.NewInventoryService(db)
+	events := services.NewSecurityEventService(db)
+	guard := services.NewLoginGuard(db, cfg.Login, events)
+	passwords := services.NewPasswordService(db, cfg, sessions, notifier, events)
+	mfa := services.NewMFAService(db, cfg, sessions.Tokens, events)
+	users := services.NewUserService(db, cfg, permissions)
+
+	return &Handlers{
+		Auth:          NewAuthHandler(db, cfg, sessions, guard, passwords, mfa, services.NewOIDCService(db, cfg.OIDC, users), users),
+		APIKey:        NewAPIKeyHandler(apiKeys),
+		Security:      NewSecurityHandler(db, guard),
+		Audit:         NewAuditHandler(db),
+		MFA:           NewMFAHandler(mfa, guard),
+		User:          NewUserHandler(db, users),
+		Role:          NewRoleHandler(db, permissions, services.NewRoleService(db, permissions), users),
+		Item:          NewItemHandler(db, services.NewItemService(db)),
+		Category:      NewCategoryHandler(db),
+		Warehouse:     NewWarehouseHandler(db),
+		Supplier:      NewSupplierHandler(db),
+		Discount:      NewDiscountHandler(db),
+		Inventory:     NewInventoryHandler(db, inventory, cfg.Reservations),
+		Adjustment:    NewAdjustmentHandler(db, services.NewAdjustmentService(db, inventory, cfg.Adjustments)),
+		PurchaseOrder: NewPurchaseOrderHandler(db, services.NewPurchaseOrderService(db, inventory)),
+		Order:         NewOrderHandler(services.NewOrderService(db, inventory)),
+		Report:        NewReportHandler(db),
+		Dashboard:     NewDashboardHandler(db, cfg),
+		Media:         NewMediaHandler(db, uploader),
+		Review:        NewReviewHandler(db),
+		Favorite:      NewFavoriteHandler(db),
+	}
+}
+
+// errorStatus maps service errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrRegistrationClosed),
+		errors.Is(err, services.ErrWarehouseForbidden),
+		errors.Is(err, services.ErrUserOutranks),
+		errors.Is(err, services.ErrSelfApproval):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrUsernameTaken),
+		errors.Is(err, services.ErrEmailTaken),
+		errors.Is(err, services.ErrLastAdmin),
+		errors.Is(err, services.ErrReservationInactive),
+		errors.Is(err, services.ErrStockReserved),
+		errors.Is(err, services.ErrStockOnHand),
+		errors.Is(err, services.ErrAdjustmentDecided),
+		errors.Is(err, services.ErrRoleNameTaken),
+		errors.Is(err, services.ErrRoleInUse),
+		errors.Is(err, services.ErrPermissionExists),
+		errors.Is(err, services.ErrCountOutdated),
+		errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidQuantity),
+		errors.Is(err, services.ErrInsufficientStock),
+		errors.Is(err, services.ErrInvalidStatus),
+		errors.Is(err, services.ErrInvalidMovement),
+		errors.Is(err, services.ErrReservationMismatch),
+		errors.Is(err, services.ErrInvalidReason),
+		errors.Is(err, services.ErrInvalidAdjustment),
+		errors.Is(err, services.ErrWeakPassword),
+		errors.Is(err, services.ErrWrongPassword),
+		errors.Is(err, services.ErrInvalidMFACode),
+		errors.Is(err, services.ErrMFANotEnrolled),
+		errors.Is(err, services.ErrMFAAlreadyEnabled),
+		errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrRoleCycle),
+		errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrInvalidPermission),
+		errors.Is(err, services.ErrUnknownPermission):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseUUID parses value as a UUID, writing a 400 response if it is invalid.
+func parseUUID(c *gin.Context, field, value string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + field})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseUUIDs parses every value as a UUID, writing a 400 response if any
+// is invalid.
+func parseUUIDs(c *gin.Context, field string, values []string) ([]uuid.UUID, bool) {
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, ok := parseUUID(c, field, value)
+		if !ok {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// warehouseScope returns the warehouses the request may touch. Without a
+// scope from the middleware it allows none.
+func warehouseScope(c *gin.Context) *services.WarehouseScope {
+	if scope, ok := c.Get("warehouseScope"); ok {
+		return scope.(*services.WarehouseScope)
+	}
+	return &services.WarehouseScope{}
+}
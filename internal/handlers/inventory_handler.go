@@ -1,34 +1,52 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
+	"fmt"
+	"go-rest/internal/config"
 	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"go-rest/internal/utils"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// InventoryHandler serves stock levels.
+type InventoryHandler struct {
+	DB           *gorm.DB
+	Inventory    *services.InventoryService
+	Reservations config.Reservations
+}
+
+func NewInventoryHandler(db *gorm.DB, inventory *services.InventoryService, reservations config.Reservations) *InventoryHandler {
+	return &InventoryHandler{DB: db, Inventory: inventory, Reservations: reservations}
+}
+
 // AddStock adds stock to a warehouse
 // AddStock godoc
 // @Summary      Add stock
-// @Description  Add stock to inventory
+// @Description  Add stock to inventory. The movement is a receipt, or a return when type is "return"; reference_id may name the order being returned.
 // @Tags         inventory
 // @Accept       json
 // @Produce      json
 // @Param        input  body      object  true  "Stock Input"
 // @Success      201    {object}  models.Inventory
 // @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
 // @Failure      500    {object}  gin.H
 // @Security     BearerAuth
 // @Router       /inventory/add [post]
-func AddStock(c *gin.Context) {
+func (h *InventoryHandler) AddStock(c *gin.Context) {
 	var input struct {
-		ItemID      string `json:"item_id"`
-		WarehouseID string `json:"warehouse_id"`
-		Quantity    int    `json:"quantity"`
+		ItemID      string  `json:"item_id"`
+		WarehouseID string  `json:"warehouse_id"`
+		Quantity    int     `json:"quantity"`
+		Type        string  `json:"type"`
+		ReferenceID *string `json:"reference_id"`
+		Note        string  `json:"note"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -36,25 +54,38 @@ func AddStock(c *gin.Context) {
 		return
 	}
 
-	var inventory models.Inventory
-	if err := database.DB.Where("item_id = ? AND warehouse_id = ?", input.ItemID, input.WarehouseID).First(&inventory).Error; err != nil {
-		// Create new inventory record
-		inventory = models.Inventory{
-			ItemID:      uuid.MustParse(input.ItemID),
-			WarehouseID: uuid.MustParse(input.WarehouseID),
-			Quantity:    input.Quantity,
-		}
-		if err := database.DB.Create(&inventory).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
-		// Update existing record
-		inventory.Quantity += input.Quantity
-		if err := database.DB.Save(&inventory).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	itemID, ok := parseUUID(c, "item_id", input.ItemID)
+	if !ok {
+		return
+	}
+	warehouseID, ok := parseUUID(c, "warehouse_id", input.WarehouseID)
+	if !ok {
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	movement := services.Movement{UserID: &userID, Note: input.Note}
+	switch input.Type {
+	case "", services.MovementReceipt:
+		movement.Type = services.MovementReceipt
+	case services.MovementReturn:
+		movement.Type = services.MovementReturn
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidMovement.Error()})
+		return
+	}
+	if input.ReferenceID != nil {
+		referenceID, ok := parseUUID(c, "reference_id", *input.ReferenceID)
+		if !ok {
 			return
 		}
+		movement.ReferenceType, movement.ReferenceID = services.ReferenceOrder, &referenceID
+	}
+
+	inventory, err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).Receive(itemID, warehouseID, input.Quantity, movement)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, inventory)
@@ -63,22 +94,24 @@ func AddStock(c *gin.Context) {
 // TransferStock moves stock from one warehouse to another
 // TransferStock godoc
 // @Summary      Transfer stock
-// @Description  Transfer stock between warehouses
+// @Description  Transfer stock between warehouses. The source warehouse must be one of yours.
 // @Tags         inventory
 // @Accept       json
 // @Produce      json
 // @Param        input  body      object  true  "Transfer Input"
 // @Success      200    {object}  gin.H
 // @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
 // @Failure      500    {object}  gin.H
 // @Security     BearerAuth
 // @Router       /inventory/transfer [post]
-func TransferStock(c *gin.Context) {
+func (h *InventoryHandler) TransferStock(c *gin.Context) {
 	var input struct {
 		ItemID          string `json:"item_id"`
 		FromWarehouseID string `json:"from_warehouse_id"`
 		ToWarehouseID   string `json:"to_warehouse_id"`
 		Quantity        int    `json:"quantity"`
+		Note            string `json:"note"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -86,44 +119,23 @@ func TransferStock(c *gin.Context) {
 		return
 	}
 
-	// Transaction
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		var fromInventory models.Inventory
-		if err := tx.Where("item_id = ? AND warehouse_id = ?", input.ItemID, input.FromWarehouseID).First(&fromInventory).Error; err != nil {
-			return err
-		}
-
-		if fromInventory.Quantity < input.Quantity {
-			return gorm.ErrInvalidData // Not enough stock
-		}
-
-		fromInventory.Quantity -= input.Quantity
-		if err := tx.Save(&fromInventory).Error; err != nil {
-			return err
-		}
-
-		var toInventory models.Inventory
-		if err := tx.Where("item_id = ? AND warehouse_id = ?", input.ItemID, input.ToWarehouseID).First(&toInventory).Error; err != nil {
-			toInventory = models.Inventory{
-				ItemID:      uuid.MustParse(input.ItemID),
-				WarehouseID: uuid.MustParse(input.ToWarehouseID),
-				Quantity:    input.Quantity,
-			}
-			if err := tx.Create(&toInventory).Error; err != nil {
-				return err
-			}
-		} else {
-			toInventory.Quantity += input.Quantity
-			if err := tx.Save(&toInventory).Error; err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
+	itemID, ok := parseUUID(c, "item_id", input.ItemID)
+	if !ok {
+		return
+	}
+	fromID, ok := parseUUID(c, "from_warehouse_id", input.FromWarehouseID)
+	if !ok {
+		return
+	}
+	toID, ok := parseUUID(c, "to_warehouse_id", input.ToWarehouseID)
+	if !ok {
+		return
+	}
 
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	userID := c.MustGet("userID").(uuid.UUID)
+	movement := services.Movement{UserID: &userID, Note: input.Note}
+	if err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).Transfer(itemID, fromID, toID, input.Quantity, movement); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -132,7 +144,7 @@ func TransferStock(c *gin.Context) {
 
 // GetInventory godoc
 // @Summary      List inventory
-// @Description  Get inventory items with filters
+// @Description  Get inventory items with filters, limited to your warehouses. Each record reports quantity on hand, reserved, and available (on hand less reserved).
 // @Tags         inventory
 // @Produce      json
 // @Param        warehouse_id  query     string  false  "Warehouse ID"
@@ -143,9 +155,9 @@ func TransferStock(c *gin.Context) {
 // @Failure      500           {object}  gin.H
 // @Security     BearerAuth
 // @Router       /inventory [get]
-func GetInventory(c *gin.Context) {
+func (h *InventoryHandler) GetInventory(c *gin.Context) {
 	var inventory []models.Inventory
-	query := database.DB.Model(&models.Inventory{})
+	query := h.DB.WithContext(c).Model(&models.Inventory{}).Scopes(warehouseScope(c).Filter("warehouse_id"))
 
 	// Filter by Warehouse if provided
 	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
@@ -159,7 +171,7 @@ func GetInventory(c *gin.Context) {
 
 	query = query.Scopes(utils.Paginate(c))
 
-	if err := query.Preload("Item").Preload("Warehouse").Find(&inventory).Error; err != nil {
+	if err := query.Find(&inventory).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -167,69 +179,226 @@ func GetInventory(c *gin.Context) {
 	c.JSON(http.StatusOK, inventory)
 }
 
-// UpdateInventory godoc
-// @Summary      Update inventory
-// @Description  Update inventory quantity manually (Correction)
+// DeleteInventory godoc
+// @Summary      Delete inventory
+// @Description  Delete an empty inventory record. Write off any remaining stock with a stock adjustment first.
 // @Tags         inventory
-// @Accept       json
 // @Produce      json
-// @Param        id     path      string  true  "Inventory ID"
-// @Param        input  body      object  true  "Quantity Input"
-// @Success      200    {object}  models.Inventory
-// @Failure      400    {object}  gin.H
-// @Failure      404    {object}  gin.H
-// @Failure      500    {object}  gin.H
+// @Param        id   path      string  true  "Inventory ID"
+// @Success      200  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/{id} [delete]
+func (h *InventoryHandler) DeleteInventory(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).Delete(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Inventory record deleted successfully"})
+}
+
+// GetStockMovements godoc
+// @Summary      List stock movements
+// @Description  Get the stock ledger, newest first, limited to your warehouses
+// @Tags         inventory
+// @Produce      json
+// @Param        item_id       query     string  false  "Item ID"
+// @Param        warehouse_id  query     string  false  "Warehouse ID"
+// @Param        type          query     string  false  "receipt, sale, transfer_out, transfer_in, adjustment or return"
+// @Param        reference_id  query     string  false  "Order, purchase order or transfer ID"
+// @Param        page          query     int     false  "Page number"
+// @Param        page_size     query     int     false  "Page size"
+// @Success      200           {array}   models.StockMovement
+// @Failure      500           {object}  gin.H
 // @Security     BearerAuth
-// @Router       /inventory/{id} [put]
-func UpdateInventory(c *gin.Context) {
-	id := c.Param("id")
-	var inventory models.Inventory
-	if err := database.DB.First(&inventory, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory record not found"})
+// @Router       /inventory/movements [get]
+func (h *InventoryHandler) GetStockMovements(c *gin.Context) {
+	var movements []models.StockMovement
+	query := h.DB.WithContext(c).Model(&models.StockMovement{}).Scopes(warehouseScope(c).Filter("warehouse_id"))
+
+	for _, column := range []string{"item_id", "warehouse_id", "type", "reference_id"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	query = query.Order("created_at desc").Scopes(utils.Paginate(c))
+
+	if err := query.Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.JSON(http.StatusOK, movements)
+}
+
+// CheckLedger godoc
+// @Summary      Check the stock ledger
+// @Description  Rebuild every balance in your warehouses from the stock ledger and list the stock records that disagree with it
+// @Tags         inventory
+// @Produce      json
+// @Success      200  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/ledger/check [get]
+func (h *InventoryHandler) CheckLedger(c *gin.Context) {
+	discrepancies, err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).CheckLedger()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"consistent":    len(discrepancies) == 0,
+		"discrepancies": discrepancies,
+	})
+}
+
+// CreateReservation godoc
+// @Summary      Reserve stock
+// @Description  Hold available stock of an item in a warehouse for a pending order. The hold is released automatically after ttl_seconds (the configured default when omitted); pass its ID as reservation_id on an order line to sell it.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Reservation Input"
+// @Success      201    {object}  models.Reservation
+// @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/reservations [post]
+func (h *InventoryHandler) CreateReservation(c *gin.Context) {
 	var input struct {
-		Quantity int `json:"quantity"`
+		ItemID      string `json:"item_id"`
+		WarehouseID string `json:"warehouse_id"`
+		Quantity    int    `json:"quantity"`
+		TTLSeconds  int    `json:"ttl_seconds"`
+		Note        string `json:"note"`
 	}
+
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	inventory.Quantity = input.Quantity
+	itemID, ok := parseUUID(c, "item_id", input.ItemID)
+	if !ok {
+		return
+	}
+	warehouseID, ok := parseUUID(c, "warehouse_id", input.WarehouseID)
+	if !ok {
+		return
+	}
+
+	ttl := h.Reservations.DefaultTTL
+	if input.TTLSeconds != 0 {
+		ttl = time.Duration(input.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > h.Reservations.MaxTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ttl_seconds must be between 1 and %d", int(h.Reservations.MaxTTL.Seconds()))})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	reservation, err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).Reserve(itemID, warehouseID, input.Quantity, ttl, userID, input.Note)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// GetReservations godoc
+// @Summary      List reservations
+// @Description  Get stock reservations, newest first, limited to your warehouses
+// @Tags         inventory
+// @Produce      json
+// @Param        item_id       query     string  false  "Item ID"
+// @Param        warehouse_id  query     string  false  "Warehouse ID"
+// @Param        status        query     string  false  "active, fulfilled, released or expired"
+// @Param        page          query     int     false  "Page number"
+// @Param        page_size     query     int     false  "Page size"
+// @Success      200           {array}   models.Reservation
+// @Failure      500           {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/reservations [get]
+func (h *InventoryHandler) GetReservations(c *gin.Context) {
+	var reservations []models.Reservation
+	query := h.DB.WithContext(c).Model(&models.Reservation{}).Scopes(warehouseScope(c).Filter("warehouse_id"))
+
+	for _, column := range []string{"item_id", "warehouse_id", "status"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	query = query.Order("created_at desc").Scopes(utils.Paginate(c))
 
-	if err := database.DB.Save(&inventory).Error; err != nil {
+	if err := query.Find(&reservations).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, inventory)
+	c.JSON(http.StatusOK, reservations)
 }
 
-// DeleteInventory godoc
-// @Summary      Delete inventory
-// @Description  Delete an inventory record
+// GetReservation godoc
+// @Summary      Get a reservation
+// @Description  Get a stock reservation by ID
 // @Tags         inventory
 // @Produce      json
-// @Param        id   path      string  true  "Inventory ID"
-// @Success      200  {object}  gin.H
+// @Param        id   path      string  true  "Reservation ID"
+// @Success      200  {object}  models.Reservation
+// @Failure      400  {object}  gin.H
 // @Failure      404  {object}  gin.H
-// @Failure      500  {object}  gin.H
 // @Security     BearerAuth
-// @Router       /inventory/{id} [delete]
-func DeleteInventory(c *gin.Context) {
-	id := c.Param("id")
-	var inventory models.Inventory
-	if err := database.DB.First(&inventory, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Inventory record not found"})
+// @Router       /inventory/reservations/{id} [get]
+func (h *InventoryHandler) GetReservation(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Delete(&inventory).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	reservation, err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).GetReservation(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Inventory record deleted successfully"})
+	c.JSON(http.StatusOK, reservation)
+}
+
+// ReleaseReservation godoc
+// @Summary      Release a reservation
+// @Description  Cancel an active reservation, making its stock available again
+// @Tags         inventory
+// @Produce      json
+// @Param        id   path      string  true  "Reservation ID"
+// @Success      200  {object}  models.Reservation
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /inventory/reservations/{id} [delete]
+func (h *InventoryHandler) ReleaseReservation(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	reservation, err := h.Inventory.WithContext(c).WithScope(warehouseScope(c)).Release(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
 }
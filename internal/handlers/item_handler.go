@@ -1,14 +1,25 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"go-rest/internal/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// ItemHandler serves items.
+type ItemHandler struct {
+	DB    *gorm.DB
+	Items *services.ItemService
+}
+
+func NewItemHandler(db *gorm.DB, items *services.ItemService) *ItemHandler {
+	return &ItemHandler{DB: db, Items: items}
+}
+
 // CreateItem godoc
 // @Summary      Create a new item
 // @Description  Create a new inventory item
@@ -21,14 +32,14 @@ import (
 // @Failure      500   {object}  gin.H
 // @Security     BearerAuth
 // @Router       /items [post]
-func CreateItem(c *gin.Context) {
+func (h *ItemHandler) CreateItem(c *gin.Context) {
 	var item models.Item
 	if err := c.ShouldBindJSON(&item); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&item).Error; err != nil {
+	if err := h.Items.WithContext(c).Create(&item); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -50,9 +61,9 @@ func CreateItem(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /items [get]
-func GetItems(c *gin.Context) {
+func (h *ItemHandler) GetItems(c *gin.Context) {
 	var items []models.Item
-	query := database.DB.Model(&models.Item{})
+	query := h.DB.WithContext(c).Model(&models.Item{})
 
 	// Search
 	query = query.Scopes(utils.Search(c, []string{"name", "description"}))
@@ -81,12 +92,15 @@ func GetItems(c *gin.Context) {
 // @Failure      404  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /items/{id} [get]
-func GetItem(c *gin.Context) {
-	id := c.Param("id")
-	var item models.Item
+func (h *ItemHandler) GetItem(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
 
-	if err := database.DB.First(&item, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	item, err := h.Items.WithContext(c).Get(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "Item not found"})
 		return
 	}
 
@@ -95,7 +109,7 @@ func GetItem(c *gin.Context) {
 
 // UpdateItem godoc
 // @Summary      Update an item
-// @Description  Update an inventory item by ID
+// @Description  Update an inventory item by ID. Send the version you read to be refused with 409 if the item has changed since.
 // @Tags         items
 // @Accept       json
 // @Produce      json
@@ -104,14 +118,12 @@ func GetItem(c *gin.Context) {
 // @Success      200   {object}  models.Item
 // @Failure      400   {object}  gin.H
 // @Failure      404   {object}  gin.H
+// @Failure      409   {object}  gin.H
 // @Security     BearerAuth
 // @Router       /items/{id} [put]
-func UpdateItem(c *gin.Context) {
-	id := c.Param("id")
-	var item models.Item
-
-	if err := database.DB.First(&item, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+func (h *ItemHandler) UpdateItem(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
 		return
 	}
 
@@ -121,14 +133,9 @@ func UpdateItem(c *gin.Context) {
 		return
 	}
 
-	// Update fields
-	item.Name = input.Name
-	item.Description = input.Description
-	item.Price = input.Price
-	// Quantity is now managed via Inventory
-
-	if err := database.DB.Save(&item).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	item, err := h.Items.WithContext(c).Update(id, input)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -145,17 +152,14 @@ func UpdateItem(c *gin.Context) {
 // @Failure      404  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /items/{id} [delete]
-func DeleteItem(c *gin.Context) {
-	id := c.Param("id")
-	var item models.Item
-
-	if err := database.DB.First(&item, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+func (h *ItemHandler) DeleteItem(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Delete(&item).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Items.WithContext(c).Delete(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -1,20 +1,30 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"go-rest/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func UploadItemMedia(c *gin.Context) {
+// MediaHandler serves item media uploads.
+type MediaHandler struct {
+	DB       *gorm.DB
+	Uploader *services.Cloudinary
+}
+
+func NewMediaHandler(db *gorm.DB, uploader *services.Cloudinary) *MediaHandler {
+	return &MediaHandler{DB: db, Uploader: uploader}
+}
+
+func (h *MediaHandler) UploadItemMedia(c *gin.Context) {
 	itemID := c.Param("id")
 
 	// Check if item exists
 	var item models.Item
-	if err := database.DB.First(&item, "id = ?", itemID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&item, "id = ?", itemID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
 		return
 	}
@@ -31,7 +41,7 @@ func UploadItemMedia(c *gin.Context) {
 		mediaType = "video"
 	}
 
-	url, publicID, err := services.UploadToCloudinary(file, "inventory/items")
+	url, publicID, err := h.Uploader.Upload(file, "inventory/items")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to Cloudinary"})
 		return
@@ -44,7 +54,7 @@ func UploadItemMedia(c *gin.Context) {
 		PublicID: publicID,
 	}
 
-	if err := database.DB.Create(&media).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&media).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"go-rest/internal/models"
+	"go-rest/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MFAHandler serves TOTP enrolment for the current user. Codes are checked
+// under the same LoginGuard as logins, so a stolen session cannot be used to
+// guess them.
+type MFAHandler struct {
+	MFA   *services.MFAService
+	Guard *services.LoginGuard
+}
+
+func NewMFAHandler(mfa *services.MFAService, guard *services.LoginGuard) *MFAHandler {
+	return &MFAHandler{MFA: mfa, Guard: guard}
+}
+
+// EnrollMFA godoc
+// @Summary      Start MFA enrolment
+// @Description  Generate a TOTP secret and its otpauth:// provisioning URI for the current user. MFA is enforced once a code is confirmed at /me/mfa/activate.
+// @Tags         mfa
+// @Produce      json
+// @Success      200  {object}  gin.H
+// @Failure      400  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me/mfa/enroll [post]
+func (h *MFAHandler) EnrollMFA(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	secret, uri, err := h.MFA.Enroll(userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": secret, "provisioning_uri": uri})
+}
+
+// ActivateMFA godoc
+// @Summary      Activate MFA
+// @Description  Confirm a TOTP code to turn MFA on. The response holds recovery codes, which are not shown again. Wrong codes are throttled like failed logins.
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "TOTP code"
+// @Success      200    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      429    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me/mfa/activate [post]
+func (h *MFAHandler) ActivateMFA(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	var codes []string
+	if !h.checkCode(c, func() (err error) {
+		codes, err = h.MFA.Activate(userID, input.Code, c.ClientIP())
+		return err
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA godoc
+// @Summary      Disable MFA
+// @Description  Turn MFA off with a TOTP or recovery code. Wrong codes are throttled like failed logins.
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "TOTP or recovery code"
+// @Success      200    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      429    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me/mfa/disable [post]
+func (h *MFAHandler) DisableMFA(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	if !h.checkCode(c, func() error {
+		return h.MFA.Disable(userID, input.Code, c.ClientIP())
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA disabled successfully"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replace every recovery code of the current user, confirmed with a TOTP code. Wrong codes are throttled like failed logins.
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "TOTP code"
+// @Success      200    {object}  gin.H
+// @Failure      400    {object}  gin.H
+// @Failure      429    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	var codes []string
+	if !h.checkCode(c, func() (err error) {
+		codes, err = h.MFA.RegenerateRecoveryCodes(userID, input.Code)
+		return err
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// checkCode runs verify, which checks an MFA code, unless the user is
+// throttled. A wrong code counts as a failed login, as it does at
+// /login/mfa. It reports whether verify succeeded; if not, it has answered.
+func (h *MFAHandler) checkCode(c *gin.Context, verify func() error) bool {
+	user := c.MustGet("user").(models.User)
+	ip := c.ClientIP()
+	if err := h.Guard.Check(user.Username, ip); err != nil {
+		tooManyAttempts(c, err)
+		return false
+	}
+
+	err := verify()
+	if errors.Is(err, services.ErrInvalidMFACode) {
+		if err := h.Guard.Failure(user.Username, ip, &user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		h.MFA.Events.Record(services.EventMFAFailed, &user.ID, user.Username, ip, "")
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return false
+	}
+	h.Guard.Reset(user.Username)
+	return true
+}
@@ -1,25 +1,31 @@
 package handlers
 
 import (
-	"errors"
-	"go-rest/internal/database"
-	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
-func CreateOrder(c *gin.Context) {
+// OrderHandler serves sales orders.
+type OrderHandler struct {
+	Orders *services.OrderService
+}
+
+func NewOrderHandler(orders *services.OrderService) *OrderHandler {
+	return &OrderHandler{Orders: orders}
+}
+
+func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var input struct {
 		WarehouseID   string `json:"warehouse_id"`
 		PaymentMethod string `json:"payment_method"`
 		Items         []struct {
-			ItemID    string  `json:"item_id"`
-			Quantity  int     `json:"quantity"`
-			UnitPrice float64 `json:"unit_price"`
+			ItemID        string  `json:"item_id"`
+			Quantity      int     `json:"quantity"`
+			UnitPrice     float64 `json:"unit_price"`
+			ReservationID *string `json:"reservation_id"`
 		} `json:"items"`
 	}
 
@@ -34,56 +40,31 @@ func CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Transaction to create order and decrease inventory
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		var totalAmount float64
-		var orderItems []models.OrderItem
-
-		for _, item := range input.Items {
-			// Check inventory
-			var inventory models.Inventory
-			if err := tx.Where("item_id = ? AND warehouse_id = ?", item.ItemID, input.WarehouseID).First(&inventory).Error; err != nil {
-				return errors.New("item not found in warehouse")
-			}
-
-			if inventory.Quantity < item.Quantity {
-				return errors.New("insufficient stock for item: " + item.ItemID)
-			}
-
-			// Deduct stock
-			inventory.Quantity -= item.Quantity
-			if err := tx.Save(&inventory).Error; err != nil {
-				return err
-			}
-
-			totalAmount += float64(item.Quantity) * item.UnitPrice
-			orderItems = append(orderItems, models.OrderItem{
-				ItemID:    uuid.MustParse(item.ItemID),
-				Quantity:  item.Quantity,
-				UnitPrice: item.UnitPrice,
-			})
-		}
+	warehouseID, ok := parseUUID(c, "warehouse_id", input.WarehouseID)
+	if !ok {
+		return
+	}
 
-		// Create Order
-		order := models.Order{
-			UserID:        userID.(uuid.UUID),
-			WarehouseID:   uuid.MustParse(input.WarehouseID),
-			TotalAmount:   totalAmount,
-			Status:        "Completed",
-			PaymentMethod: input.PaymentMethod,
-			Date:          time.Now(),
-			Items:         orderItems,
+	var lines []services.OrderLine
+	for _, item := range input.Items {
+		itemID, ok := parseUUID(c, "item_id", item.ItemID)
+		if !ok {
+			return
 		}
-
-		if err := tx.Create(&order).Error; err != nil {
-			return err
+		line := services.OrderLine{ItemID: itemID, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+		if item.ReservationID != nil {
+			reservationID, ok := parseUUID(c, "reservation_id", *item.ReservationID)
+			if !ok {
+				return
+			}
+			line.ReservationID = &reservationID
 		}
+		lines = append(lines, line)
+	}
 
-		return nil
-	})
-
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Creates the order and decreases inventory in one transaction
+	if _, err := h.Orders.WithContext(c).WithScope(warehouseScope(c)).Create(userID.(uuid.UUID), warehouseID, input.PaymentMethod, lines); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
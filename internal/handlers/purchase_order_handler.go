@@ -1,17 +1,26 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"go-rest/internal/utils"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// PurchaseOrderHandler serves purchase orders.
+type PurchaseOrderHandler struct {
+	DB             *gorm.DB
+	PurchaseOrders *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(db *gorm.DB, purchaseOrders *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{DB: db, PurchaseOrders: purchaseOrders}
+}
+
 // CreatePurchaseOrder godoc
 // @Summary      Create a purchase order
 // @Description  Create a new purchase order
@@ -21,10 +30,11 @@ import (
 // @Param        input  body      object  true  "Purchase Order Input"
 // @Success      201    {object}  models.PurchaseOrder
 // @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
 // @Failure      500    {object}  gin.H
 // @Security     BearerAuth
 // @Router       /purchase-orders [post]
-func CreatePurchaseOrder(c *gin.Context) {
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
 	var input struct {
 		SupplierID  string `json:"supplier_id"`
 		WarehouseID string `json:"warehouse_id"`
@@ -40,29 +50,27 @@ func CreatePurchaseOrder(c *gin.Context) {
 		return
 	}
 
-	// Calculate total amount
-	var totalAmount float64
-	var poItems []models.PurchaseOrderItem
-	for _, item := range input.Items {
-		totalAmount += float64(item.Quantity) * item.UnitPrice
-		poItems = append(poItems, models.PurchaseOrderItem{
-			ItemID:    uuid.MustParse(item.ItemID),
-			Quantity:  item.Quantity,
-			UnitPrice: item.UnitPrice,
-		})
+	supplierID, ok := parseUUID(c, "supplier_id", input.SupplierID)
+	if !ok {
+		return
+	}
+	warehouseID, ok := parseUUID(c, "warehouse_id", input.WarehouseID)
+	if !ok {
+		return
 	}
 
-	po := models.PurchaseOrder{
-		SupplierID:  uuid.MustParse(input.SupplierID),
-		WarehouseID: uuid.MustParse(input.WarehouseID),
-		Status:      "Pending",
-		TotalAmount: totalAmount,
-		Date:        time.Now(),
-		Items:       poItems,
+	var lines []services.OrderLine
+	for _, item := range input.Items {
+		itemID, ok := parseUUID(c, "item_id", item.ItemID)
+		if !ok {
+			return
+		}
+		lines = append(lines, services.OrderLine{ItemID: itemID, Quantity: item.Quantity, UnitPrice: item.UnitPrice})
 	}
 
-	if err := database.DB.Create(&po).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	po, err := h.PurchaseOrders.WithContext(c).WithScope(warehouseScope(c)).Create(supplierID, warehouseID, lines)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -71,7 +79,7 @@ func CreatePurchaseOrder(c *gin.Context) {
 
 // UpdatePurchaseOrderStatus godoc
 // @Summary      Update purchase order status
-// @Description  Update status (e.g., Pending -> Received). Updates inventory if Received.
+// @Description  Update status (e.g., Pending -> Received). Updates inventory if Received. Send the version you read to be refused with 409 if the order has changed since.
 // @Tags         purchase_orders
 // @Accept       json
 // @Produce      json
@@ -80,13 +88,19 @@ func CreatePurchaseOrder(c *gin.Context) {
 // @Success      200    {object}  models.PurchaseOrder
 // @Failure      400    {object}  gin.H
 // @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
 // @Failure      500    {object}  gin.H
 // @Security     BearerAuth
 // @Router       /purchase-orders/{id}/status [put]
-func UpdatePurchaseOrderStatus(c *gin.Context) {
-	id := c.Param("id")
+func (h *PurchaseOrderHandler) UpdatePurchaseOrderStatus(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
 	var input struct {
-		Status string `json:"status"`
+		Status  string `json:"status"`
+		Version int    `json:"version"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -94,46 +108,11 @@ func UpdatePurchaseOrderStatus(c *gin.Context) {
 		return
 	}
 
-	var po models.PurchaseOrder
-	if err := database.DB.Preload("Items").First(&po, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase Order not found"})
-		return
-	}
-
-	// If status changes to Received, update inventory
-	if input.Status == "Received" && po.Status != "Received" {
-		err := database.DB.Transaction(func(tx *gorm.DB) error {
-			for _, item := range po.Items {
-				var inventory models.Inventory
-				if err := tx.Where("item_id = ? AND warehouse_id = ?", item.ItemID, po.WarehouseID).First(&inventory).Error; err != nil {
-					// Create if not exists
-					inventory = models.Inventory{
-						ItemID:      item.ItemID,
-						WarehouseID: po.WarehouseID,
-						Quantity:    item.Quantity,
-					}
-					if err := tx.Create(&inventory).Error; err != nil {
-						return err
-					}
-				} else {
-					inventory.Quantity += item.Quantity
-					if err := tx.Save(&inventory).Error; err != nil {
-						return err
-					}
-				}
-			}
-			return nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inventory"})
-			return
-		}
-	}
-
-	po.Status = input.Status
-	if err := database.DB.Save(&po).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Receiving a purchase order also puts its items into stock
+	userID := c.MustGet("userID").(uuid.UUID)
+	po, err := h.PurchaseOrders.WithContext(c).WithScope(warehouseScope(c)).UpdateStatus(id, input.Status, input.Version, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -142,7 +121,7 @@ func UpdatePurchaseOrderStatus(c *gin.Context) {
 
 // GetPurchaseOrders godoc
 // @Summary      List purchase orders
-// @Description  Get all purchase orders with pagination, search, and sort
+// @Description  Get purchase orders for your warehouses with pagination, search, and sort
 // @Tags         purchase_orders
 // @Produce      json
 // @Param        page       query     int     false  "Page number"
@@ -154,9 +133,9 @@ func UpdatePurchaseOrderStatus(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /purchase-orders [get]
-func GetPurchaseOrders(c *gin.Context) {
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
 	var pos []models.PurchaseOrder
-	query := database.DB.Model(&models.PurchaseOrder{})
+	query := h.DB.WithContext(c).Model(&models.PurchaseOrder{}).Scopes(warehouseScope(c).Filter("warehouse_id"))
 
 	query = query.Scopes(utils.Search(c, []string{"status"})) // Basic search by status
 	query = query.Scopes(utils.Sort(c, map[string]bool{"date": true, "total_amount": true}))
@@ -181,16 +160,14 @@ func GetPurchaseOrders(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /purchase-orders/{id} [delete]
-func DeletePurchaseOrder(c *gin.Context) {
-	id := c.Param("id")
-	var po models.PurchaseOrder
-	if err := database.DB.First(&po, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase Order not found"})
+func (h *PurchaseOrderHandler) DeletePurchaseOrder(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Delete(&po).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.PurchaseOrders.WithContext(c).WithScope(warehouseScope(c)).Delete(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
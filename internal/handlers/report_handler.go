@@ -7,9 +7,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func GetFinancialReport(c *gin.Context) {
+// ReportHandler serves financial and sales reports.
+type ReportHandler struct {
+	DB *gorm.DB
+}
+
+func NewReportHandler(db *gorm.DB) *ReportHandler {
+	return &ReportHandler{DB: db}
+}
+
+func (h *ReportHandler) GetFinancialReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 
@@ -26,13 +36,13 @@ func GetFinancialReport(c *gin.Context) {
 	}
 
 	var revenue float64
-	database.DB.Model(&models.Order{}).
+	h.DB.WithContext(c).Model(&models.Order{}).Scopes(warehouseScope(c).Filter("warehouse_id")).
 		Where("date BETWEEN ? AND ?", startDate, endDate).
 		Select("sum(total_amount)").
 		Scan(&revenue)
 
 	var cost float64
-	database.DB.Model(&models.PurchaseOrder{}).
+	h.DB.WithContext(c).Model(&models.PurchaseOrder{}).Scopes(warehouseScope(c).Filter("warehouse_id")).
 		Where("date BETWEEN ? AND ?", startDate, endDate).
 		Select("sum(total_amount)").
 		Scan(&cost)
@@ -46,24 +56,26 @@ func GetFinancialReport(c *gin.Context) {
 
 // GetSalesReport godoc
 // @Summary      Get sales report
-// @Description  Get daily sales aggregation
+// @Description  Get daily sales aggregation for your warehouses
 // @Tags         reports
 // @Produce      json
 // @Success      200  {array}   object
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /reports/sales [get]
-func GetSalesReport(c *gin.Context) {
+func (h *ReportHandler) GetSalesReport(c *gin.Context) {
 	type SalesData struct {
 		Date       string  `json:"date"`
 		TotalSales float64 `json:"total_sales"`
 		OrderCount int     `json:"order_count"`
 	}
 
+	day := database.DateExpr(h.DB, "date")
+
 	var sales []SalesData
-	database.DB.Model(&models.Order{}).
-		Select("date(date) as date, sum(total_amount) as total_sales, count(id) as order_count").
-		Group("date(date)").
+	h.DB.WithContext(c).Model(&models.Order{}).Scopes(warehouseScope(c).Filter("warehouse_id")).
+		Select(day + " as date, sum(total_amount) as total_sales, count(id) as order_count").
+		Group(day).
 		Scan(&sales)
 
 	c.JSON(http.StatusOK, sales)
@@ -1,15 +1,24 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func CreateReview(c *gin.Context) {
+// ReviewHandler serves reviews.
+type ReviewHandler struct {
+	DB *gorm.DB
+}
+
+func NewReviewHandler(db *gorm.DB) *ReviewHandler {
+	return &ReviewHandler{DB: db}
+}
+
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	itemID := c.Param("id")
 
 	// Get user ID from token (set by middleware)
@@ -62,7 +71,7 @@ func CreateReview(c *gin.Context) {
 	}
 	review.UserID = userID.(uuid.UUID)
 
-	if err := database.DB.Create(&review).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&review).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
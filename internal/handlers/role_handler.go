@@ -1,36 +1,78 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// CreateRole creates a new role
+// RoleHandler serves roles and permissions.
+type RoleHandler struct {
+	DB    *gorm.DB
+	Cache *services.PermissionCache
+	Roles *services.RoleService
+	Users *services.UserService
+}
+
+func NewRoleHandler(db *gorm.DB, cache *services.PermissionCache, roles *services.RoleService, users *services.UserService) *RoleHandler {
+	return &RoleHandler{DB: db, Cache: cache, Roles: roles, Users: users}
+}
+
+// roleInput is the JSON body of role create and update requests.
+type roleInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	RequireMFA  *bool   `json:"require_mfa"`
+	ParentID    *string `json:"parent_id"`
+}
+
+func (in roleInput) role() services.RoleInput {
+	return services.RoleInput{Name: in.Name, Description: in.Description, RequireMFA: in.RequireMFA}
+}
+
+// permissionInput is the JSON body of permission create and update requests.
+type permissionInput struct {
+	Resource string `json:"resource" binding:"required"`
+	Action   string `json:"action" binding:"required"`
+}
+
 // CreateRole godoc
 // @Summary      Create a role
-// @Description  Create a new role
+// @Description  Create a new role, optionally inheriting from parent_id
 // @Tags         rbac
 // @Accept       json
 // @Produce      json
-// @Param        role  body      models.Role  true  "Role JSON"
+// @Param        role  body      object  true  "name, description, require_mfa, parent_id"
 // @Success      201   {object}  models.Role
 // @Failure      400   {object}  gin.H
+// @Failure      404   {object}  gin.H
+// @Failure      409   {object}  gin.H
 // @Failure      500   {object}  gin.H
 // @Security     BearerAuth
 // @Router       /rbac/roles [post]
-func CreateRole(c *gin.Context) {
-	var role models.Role
-	if err := c.ShouldBindJSON(&role); err != nil {
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var input roleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&role).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var parentID *uuid.UUID
+	if input.ParentID != nil {
+		parsed, ok := parseUUID(c, "parent_id", *input.ParentID)
+		if !ok {
+			return
+		}
+		parentID = &parsed
+	}
+
+	role, err := h.Roles.WithContext(c).Create(input.role(), parentID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -46,9 +88,9 @@ func CreateRole(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /rbac/roles [get]
-func GetRoles(c *gin.Context) {
+func (h *RoleHandler) GetRoles(c *gin.Context) {
 	var roles []models.Role
-	if err := database.DB.Preload("Permissions").Find(&roles).Error; err != nil {
+	if err := h.DB.WithContext(c).Preload("Permissions").Find(&roles).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -56,27 +98,119 @@ func GetRoles(c *gin.Context) {
 	c.JSON(http.StatusOK, roles)
 }
 
+// GetRole godoc
+// @Summary      Get a role
+// @Description  Get a role with the permissions it grants directly
+// @Tags         rbac
+// @Produce      json
+// @Param        id   path      string  true  "Role ID"
+// @Success      200  {object}  models.Role
+// @Failure      400  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	role, err := h.Roles.WithContext(c).Get(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// UpdateRole godoc
+// @Summary      Update a role
+// @Description  Change a role's name, description or MFA requirement. Use the parent and permissions endpoints for the rest.
+// @Tags         rbac
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "Role ID"
+// @Param        input  body      object  true  "name, description, require_mfa"
+// @Success      200    {object}  models.Role
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input roleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.Roles.WithContext(c).Update(id, input.role())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole godoc
+// @Summary      Delete a role
+// @Description  Delete a role that is not assigned to any user and that no role inherits from
+// @Tags         rbac
+// @Produce      json
+// @Param        id   path      string  true  "Role ID"
+// @Success      200  {object}  gin.H
+// @Failure      400  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.Roles.WithContext(c).Delete(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
 // CreatePermission godoc
 // @Summary      Create a permission
-// @Description  Create a new permission
+// @Description  Create a new permission. Resources may be dotted paths (inventory.transfer) and either side may be a * wildcard (items:*, *:read, inventory.*:write). It must cover at least one permission in the catalog.
 // @Tags         rbac
 // @Accept       json
 // @Produce      json
-// @Param        permission  body      models.Permission  true  "Permission JSON"
+// @Param        permission  body      object  true  "resource, action"
 // @Success      201         {object}  models.Permission
 // @Failure      400         {object}  gin.H
+// @Failure      409         {object}  gin.H
 // @Failure      500         {object}  gin.H
 // @Security     BearerAuth
 // @Router       /rbac/permissions [post]
-func CreatePermission(c *gin.Context) {
-	var permission models.Permission
-	if err := c.ShouldBindJSON(&permission); err != nil {
+func (h *RoleHandler) CreatePermission(c *gin.Context) {
+	var input permissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&permission).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	permission, err := h.Roles.WithContext(c).CreatePermission(input.Resource, input.Action)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -92,9 +226,9 @@ func CreatePermission(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /rbac/permissions [get]
-func GetPermissions(c *gin.Context) {
+func (h *RoleHandler) GetPermissions(c *gin.Context) {
 	var permissions []models.Permission
-	if err := database.DB.Find(&permissions).Error; err != nil {
+	if err := h.DB.WithContext(c).Find(&permissions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -102,54 +236,280 @@ func GetPermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, permissions)
 }
 
+// GetPermissionCatalog godoc
+// @Summary      Permission catalog
+// @Description  Every resource:action the API checks, collected from the routes at startup
+// @Tags         rbac
+// @Produce      json
+// @Success      200  {array}  string
+// @Security     BearerAuth
+// @Router       /rbac/permissions/catalog [get]
+func (h *RoleHandler) GetPermissionCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, services.PermissionCatalog())
+}
+
+// GetPermission godoc
+// @Summary      Get a permission
+// @Description  Get a permission by ID
+// @Tags         rbac
+// @Produce      json
+// @Param        id   path      string  true  "Permission ID"
+// @Success      200  {object}  models.Permission
+// @Failure      400  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/permissions/{id} [get]
+func (h *RoleHandler) GetPermission(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	permission, err := h.Roles.WithContext(c).GetPermission(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, permission)
+}
+
+// UpdatePermission godoc
+// @Summary      Update a permission
+// @Description  Change what a permission grants, for every role and API key holding it
+// @Tags         rbac
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "Permission ID"
+// @Param        input  body      object  true  "resource, action"
+// @Success      200    {object}  models.Permission
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/permissions/{id} [put]
+func (h *RoleHandler) UpdatePermission(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input permissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	permission, err := h.Roles.WithContext(c).UpdatePermission(id, input.Resource, input.Action)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, permission)
+}
+
+// DeletePermission godoc
+// @Summary      Delete a permission
+// @Description  Delete a permission and take it away from every role and API key
+// @Tags         rbac
+// @Produce      json
+// @Param        id   path      string  true  "Permission ID"
+// @Success      200  {object}  gin.H
+// @Failure      400  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/permissions/{id} [delete]
+func (h *RoleHandler) DeletePermission(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.Roles.WithContext(c).DeletePermission(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permission deleted successfully"})
+}
+
 // AssignPermissionsToRole godoc
 // @Summary      Assign permissions to role
-// @Description  Assign a list of permissions to a role
+// @Description  Replace a role's permissions. Every ID must name an existing permission.
 // @Tags         rbac
 // @Accept       json
 // @Produce      json
 // @Param        id     path      string  true  "Role ID"
 // @Param        input  body      object  true  "Permission IDs"
-// @Success      200    {object}  gin.H
+// @Success      200    {object}  models.Role
 // @Failure      400    {object}  gin.H
 // @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
 // @Failure      500    {object}  gin.H
 // @Security     BearerAuth
 // @Router       /rbac/roles/{id}/permissions [post]
-func AssignPermissionsToRole(c *gin.Context) {
-	roleID := c.Param("id")
+func (h *RoleHandler) AssignPermissionsToRole(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
 	var input struct {
 		PermissionIDs []string `json:"permission_ids"`
 	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	permissionIDs, ok := parseUUIDs(c, "permission_ids", input.PermissionIDs)
+	if !ok {
+		return
+	}
+
+	role, err := h.Roles.WithContext(c).SetPermissions(id, permissionIDs)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// SetRoleMFA godoc
+// @Summary      Require MFA for a role
+// @Description  Set whether members of a role must sign in with a second factor before any permission is granted
+// @Tags         rbac
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "Role ID"
+// @Param        input  body      object  true  "require_mfa"
+// @Success      200    {object}  models.Role
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/roles/{id}/mfa [put]
+func (h *RoleHandler) SetRoleMFA(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input struct {
+		RequireMFA *bool `json:"require_mfa" binding:"required"`
+	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var role models.Role
-	if err := database.DB.First(&role, "id = ?", roleID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+	role, err := h.Roles.WithContext(c).Update(id, services.RoleInput{RequireMFA: input.RequireMFA})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	var permissions []models.Permission
-	if err := database.DB.Where("id IN ?", input.PermissionIDs).Find(&permissions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, role)
+}
+
+// SetRoleParent godoc
+// @Summary      Set a role's parent
+// @Description  Make a role inherit the permissions and MFA requirement of another role, or of none when parent_id is null
+// @Tags         rbac
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "Role ID"
+// @Param        input  body      object  true  "parent_id"
+// @Success      200    {object}  models.Role
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/roles/{id}/parent [put]
+func (h *RoleHandler) SetRoleParent(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Model(&role).Association("Permissions").Replace(permissions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var input struct {
+		ParentID *string `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var parentID *uuid.UUID
+	if input.ParentID != nil {
+		parsed, ok := parseUUID(c, "parent_id", *input.ParentID)
+		if !ok {
+			return
+		}
+		parentID = &parsed
+	}
+
+	role, err := h.Roles.WithContext(c).SetParent(id, parentID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Permissions assigned successfully"})
+	c.JSON(http.StatusOK, role)
 }
 
 // AssignRoleToUser godoc
 // @Summary      Assign role to user
-// @Description  Assign a role to a user
+// @Description  Give a user another role, in addition to the ones they have
+// @Tags         rbac
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "User ID"
+// @Param        input  body      object  true  "Role ID"
+// @Success      200    {object}  models.User
+// @Failure      400    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/users/{id}/roles [post]
+func (h *RoleHandler) AssignRoleToUser(c *gin.Context) {
+	userID, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input struct {
+		RoleID string `json:"role_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	roleID, ok := parseUUID(c, "role_id", input.RoleID)
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.WithContext(c).AddRole(userID, roleID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserRole godoc
+// @Summary      Set a user's only role
+// @Description  Replace every role of a user with the given one. This is the endpoint from when users had a single role; use /rbac/users/{id}/roles to add roles instead. The last admin cannot be demoted, and the roles of a user with permissions you do not have cannot be changed.
 // @Tags         rbac
 // @Accept       json
 // @Produce      json
@@ -157,32 +517,82 @@ func AssignPermissionsToRole(c *gin.Context) {
 // @Param        input  body      object  true  "Role ID"
 // @Success      200    {object}  gin.H
 // @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
 // @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
 // @Failure      500    {object}  gin.H
 // @Security     BearerAuth
 // @Router       /rbac/users/{id}/role [post]
-func AssignRoleToUser(c *gin.Context) {
-	userID := c.Param("id")
+func (h *RoleHandler) SetUserRole(c *gin.Context) {
+	userID, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
 	var input struct {
 		RoleID string `json:"role_id"`
 	}
-
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var user models.User
-	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	roleID, ok := parseUUID(c, "role_id", input.RoleID)
+	if !ok {
 		return
 	}
 
-	user.RoleID = uuid.MustParse(input.RoleID)
-	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	roles := []uuid.UUID{roleID}
+	update := services.UserUpdate{RoleIDs: &roles}
+	if _, err := h.Users.WithContext(c).Update(userID, update, c.MustGet("permissions").(map[string]bool)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
 }
+
+// RemoveRoleFromUser godoc
+// @Summary      Remove role from user
+// @Description  Take a role away from a user. The last admin cannot lose their admin role.
+// @Tags         rbac
+// @Produce      json
+// @Param        id       path      string  true  "User ID"
+// @Param        role_id  path      string  true  "Role ID"
+// @Success      200      {object}  models.User
+// @Failure      400      {object}  gin.H
+// @Failure      404      {object}  gin.H
+// @Failure      409      {object}  gin.H
+// @Failure      500      {object}  gin.H
+// @Security     BearerAuth
+// @Router       /rbac/users/{id}/roles/{role_id} [delete]
+func (h *RoleHandler) RemoveRoleFromUser(c *gin.Context) {
+	userID, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	roleID, ok := parseUUID(c, "role_id", c.Param("role_id"))
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.WithContext(c).RemoveRole(userID, roleID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetPermissionCacheStats godoc
+// @Summary      Permission cache statistics
+// @Description  Hit rate and size of the in-memory cache of role permissions and user roles
+// @Tags         rbac
+// @Produce      json
+// @Success      200  {object}  services.PermissionCacheStats
+// @Security     BearerAuth
+// @Router       /rbac/cache [get]
+func (h *RoleHandler) GetPermissionCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Cache.Stats())
+}
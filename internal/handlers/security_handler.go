@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"go-rest/internal/models"
+	"go-rest/internal/services"
+	"go-rest/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecurityHandler serves account lockouts and the security event log.
+type SecurityHandler struct {
+	DB    *gorm.DB
+	Guard *services.LoginGuard
+}
+
+func NewSecurityHandler(db *gorm.DB, guard *services.LoginGuard) *SecurityHandler {
+	return &SecurityHandler{DB: db, Guard: guard}
+}
+
+// GetSecurityEvents godoc
+// @Summary      List security events
+// @Description  Get login, lockout and unlock events, newest first
+// @Tags         security
+// @Produce      json
+// @Param        type       query     string  false  "Event type"
+// @Param        username   query     string  false  "Username"
+// @Param        ip         query     string  false  "Client IP"
+// @Param        page       query     int     false  "Page number"
+// @Param        page_size  query     int     false  "Page size"
+// @Success      200  {array}   models.SecurityEvent
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /security/events [get]
+func (h *SecurityHandler) GetSecurityEvents(c *gin.Context) {
+	var events []models.SecurityEvent
+	query := h.DB.WithContext(c).Model(&models.SecurityEvent{})
+
+	if eventType := c.Query("type"); eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+	if username := c.Query("username"); username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+
+	query = query.Order("created_at desc").Scopes(utils.Paginate(c))
+
+	if err := query.Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// UnlockUser godoc
+// @Summary      Unlock a user
+// @Description  Clear failed login attempts and any lockout for a user
+// @Tags         security
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /security/users/{id}/unlock [post]
+func (h *SecurityHandler) UnlockUser(c *gin.Context) {
+	var user models.User
+	if err := h.DB.WithContext(c).First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	actorID := c.MustGet("userID").(uuid.UUID)
+	if err := h.Guard.Unlock(user.Username, &actorID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
@@ -1,14 +1,23 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"go-rest/internal/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// SupplierHandler serves suppliers.
+type SupplierHandler struct {
+	DB *gorm.DB
+}
+
+func NewSupplierHandler(db *gorm.DB) *SupplierHandler {
+	return &SupplierHandler{DB: db}
+}
+
 // CreateSupplier godoc
 // @Summary      Create a supplier
 // @Description  Create a new supplier
@@ -23,14 +32,14 @@ import (
 // @Failure      500           {object}  gin.H
 // @Security     BearerAuth
 // @Router       /suppliers [post]
-func CreateSupplier(c *gin.Context) {
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
 	var supplier models.Supplier
 	if err := c.ShouldBind(&supplier); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&supplier).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&supplier).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -52,9 +61,9 @@ func CreateSupplier(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /suppliers [get]
-func GetSuppliers(c *gin.Context) {
+func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
 	var suppliers []models.Supplier
-	query := database.DB.Model(&models.Supplier{})
+	query := h.DB.WithContext(c).Model(&models.Supplier{})
 
 	query = query.Scopes(utils.Search(c, []string{"name", "contact_info", "address"}))
 	query = query.Scopes(utils.Sort(c, map[string]bool{"name": true}))
@@ -84,10 +93,10 @@ func GetSuppliers(c *gin.Context) {
 // @Failure      500           {object}  gin.H
 // @Security     BearerAuth
 // @Router       /suppliers/{id} [put]
-func UpdateSupplier(c *gin.Context) {
+func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
 	id := c.Param("id")
 	var supplier models.Supplier
-	if err := database.DB.First(&supplier, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&supplier, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
 		return
 	}
@@ -102,7 +111,7 @@ func UpdateSupplier(c *gin.Context) {
 	supplier.ContactInfo = input.ContactInfo
 	supplier.Address = input.Address
 
-	if err := database.DB.Save(&supplier).Error; err != nil {
+	if err := h.DB.WithContext(c).Save(&supplier).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -121,15 +130,15 @@ func UpdateSupplier(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /suppliers/{id} [delete]
-func DeleteSupplier(c *gin.Context) {
+func (h *SupplierHandler) DeleteSupplier(c *gin.Context) {
 	id := c.Param("id")
 	var supplier models.Supplier
-	if err := database.DB.First(&supplier, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&supplier, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&supplier).Error; err != nil {
+	if err := h.DB.WithContext(c).Delete(&supplier).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"go-rest/internal/models"
+	"go-rest/internal/services"
+	"go-rest/internal/utils"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserHandler serves user administration and the current user.
+type UserHandler struct {
+	DB    *gorm.DB
+	Users *services.UserService
+}
+
+func NewUserHandler(db *gorm.DB, users *services.UserService) *UserHandler {
+	return &UserHandler{DB: db, Users: users}
+}
+
+// CreateUser godoc
+// @Summary      Create a user
+// @Description  Create a user with the given roles, or the default role. This is how accounts are made in invite-only mode. Giving role_ids requires roles:write.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        input  body      object  true  "Username, password, email and role_ids"
+// @Success      201    {object}  models.User
+// @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /users [post]
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var input struct {
+		Username string   `json:"username" binding:"required"`
+		Password string   `json:"password" binding:"required"`
+		Email    string   `json:"email" binding:"omitempty,email"`
+		RoleIDs  []string `json:"role_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(input.RoleIDs) > 0 && !canAssignRoles(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Assigning roles requires roles:write"})
+		return
+	}
+
+	newUser := services.NewUser{Username: input.Username, Password: input.Password, Email: input.Email}
+	roleIDs, ok := parseUUIDs(c, "role_ids", input.RoleIDs)
+	if !ok {
+		return
+	}
+	newUser.RoleIDs = roleIDs
+
+	user, err := h.Users.WithContext(c).Create(newUser)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetUsers godoc
+// @Summary      List users
+// @Description  Get users with pagination, search, sort and filters
+// @Tags         users
+// @Produce      json
+// @Param        page       query     int     false  "Page number"
+// @Param        page_size  query     int     false  "Page size"
+// @Param        search     query     string  false  "Search username or email"
+// @Param        sort       query     string  false  "Sort field (username, email, created_at)"
+// @Param        order      query     string  false  "Sort order (asc/desc)"
+// @Param        role_id    query     string  false  "Role ID"
+// @Param        disabled   query     bool    false  "Disabled"
+// @Success      200  {array}   models.User
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /users [get]
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	var users []models.User
+	query := h.DB.WithContext(c).Model(&models.User{})
+
+	if roleID := c.Query("role_id"); roleID != "" {
+		query = query.Where("id IN (?)", h.DB.WithContext(c).Model(&models.UserRole{}).Select("user_id").Where("role_id = ?", roleID))
+	}
+	if disabled := c.Query("disabled"); disabled != "" {
+		query = query.Where("disabled = ?", disabled == "true")
+	}
+
+	query = query.Scopes(utils.Search(c, []string{"username", "email"}))
+	query = query.Scopes(utils.Sort(c, map[string]bool{"username": true, "email": true, "created_at": true}))
+	query = query.Scopes(utils.Paginate(c))
+
+	if err := query.Preload("Roles").Preload("Warehouses").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary      Get a user
+// @Description  Get a user by ID
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  models.User
+// @Failure      404  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.WithContext(c).Get(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser godoc
+// @Summary      Update a user
+// @Description  Change a user's email, roles, disabled flag or assigned warehouses. Disabling signs the user out everywhere. The last admin cannot be disabled or demoted. Changing roles requires roles:write, and the email, roles or disabled flag of a user with permissions you do not have cannot be changed.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true  "User ID"
+// @Param        input  body      object  true  "email, role_ids, disabled, warehouse_ids"
+// @Success      200    {object}  models.User
+// @Failure      400    {object}  gin.H
+// @Failure      403    {object}  gin.H
+// @Failure      404    {object}  gin.H
+// @Failure      409    {object}  gin.H
+// @Failure      500    {object}  gin.H
+// @Security     BearerAuth
+// @Router       /users/{id} [put]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Email        *string   `json:"email" binding:"omitempty,email"`
+		RoleIDs      *[]string `json:"role_ids"`
+		Disabled     *bool     `json:"disabled"`
+		WarehouseIDs *[]string `json:"warehouse_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := services.UserUpdate{Email: input.Email, Disabled: input.Disabled}
+	if input.RoleIDs != nil {
+		if !canAssignRoles(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Assigning roles requires roles:write"})
+			return
+		}
+		ids, ok := parseUUIDs(c, "role_ids", *input.RoleIDs)
+		if !ok {
+			return
+		}
+		update.RoleIDs = &ids
+	}
+	if input.WarehouseIDs != nil {
+		ids, ok := parseUUIDs(c, "warehouse_ids", *input.WarehouseIDs)
+		if !ok {
+			return
+		}
+		update.WarehouseIDs = &ids
+	}
+
+	user, err := h.Users.WithContext(c).Update(id, update, c.MustGet("permissions").(map[string]bool))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary      Delete a user
+// @Description  Delete a user and revoke their sessions. Neither the last admin nor a user with permissions you do not have can be deleted.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  gin.H
+// @Failure      403  {object}  gin.H
+// @Failure      404  {object}  gin.H
+// @Failure      409  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /users/{id} [delete]
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.Users.WithContext(c).Delete(id, c.MustGet("permissions").(map[string]bool)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// GetMe godoc
+// @Summary      Current user
+// @Description  Get the signed-in user and their effective permissions. For API keys these are the key's scopes.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me [get]
+func (h *UserHandler) GetMe(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	perms := c.MustGet("permissions").(map[string]bool)
+
+	permissions := make([]string, 0, len(perms))
+	for name := range perms {
+		permissions = append(permissions, name)
+	}
+	sort.Strings(permissions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":        user,
+		"permissions": permissions,
+		"mfa":         c.GetBool("mfa"),
+	})
+}
+
+// GetUserPermissions godoc
+// @Summary      Explain a user's permissions
+// @Description  List every permission a user's roles grant, with the role it comes from and the ancestor it is inherited from
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {array}   services.PermissionSource
+// @Failure      404  {object}  gin.H
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /users/{id}/permissions [get]
+func (h *UserHandler) GetUserPermissions(c *gin.Context) {
+	id, ok := parseUUID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	h.permissionSources(c, id)
+}
+
+// GetMyPermissions godoc
+// @Summary      Explain my permissions
+// @Description  List every permission the signed-in user's roles grant and where each comes from. API key scopes are not applied.
+// @Tags         users
+// @Produce      json
+// @Success      200  {array}   services.PermissionSource
+// @Failure      500  {object}  gin.H
+// @Security     BearerAuth
+// @Router       /me/permissions [get]
+func (h *UserHandler) GetMyPermissions(c *gin.Context) {
+	h.permissionSources(c, c.MustGet("userID").(uuid.UUID))
+}
+
+func (h *UserHandler) permissionSources(c *gin.Context, userID uuid.UUID) {
+	sources, err := h.Users.WithContext(c).PermissionSources(userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+// canAssignRoles reports whether the caller may choose a user's roles, which
+// takes the same permission as the RBAC role assignment endpoints.
+func canAssignRoles(c *gin.Context) bool {
+	return services.HasPermission(c.MustGet("permissions").(map[string]bool), "roles", "write")
+}
@@ -1,14 +1,23 @@
 package handlers
 
 import (
-	"go-rest/internal/database"
 	"go-rest/internal/models"
 	"go-rest/internal/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// WarehouseHandler serves warehouses.
+type WarehouseHandler struct {
+	DB *gorm.DB
+}
+
+func NewWarehouseHandler(db *gorm.DB) *WarehouseHandler {
+	return &WarehouseHandler{DB: db}
+}
+
 // CreateWarehouse godoc
 // @Summary      Create a warehouse
 // @Description  Create a new warehouse
@@ -23,14 +32,14 @@ import (
 // @Failure      500       {object}  gin.H
 // @Security     BearerAuth
 // @Router       /warehouses [post]
-func CreateWarehouse(c *gin.Context) {
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
 	var warehouse models.Warehouse
 	if err := c.ShouldBind(&warehouse); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := database.DB.Create(&warehouse).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&warehouse).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -52,9 +61,9 @@ func CreateWarehouse(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /warehouses [get]
-func GetWarehouses(c *gin.Context) {
+func (h *WarehouseHandler) GetWarehouses(c *gin.Context) {
 	var warehouses []models.Warehouse
-	query := database.DB.Model(&models.Warehouse{})
+	query := h.DB.WithContext(c).Model(&models.Warehouse{})
 
 	query = query.Scopes(utils.Search(c, []string{"name", "location"}))
 	query = query.Scopes(utils.Sort(c, map[string]bool{"name": true, "capacity": true}))
@@ -84,10 +93,10 @@ func GetWarehouses(c *gin.Context) {
 // @Failure      500       {object}  gin.H
 // @Security     BearerAuth
 // @Router       /warehouses/{id} [put]
-func UpdateWarehouse(c *gin.Context) {
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
 	id := c.Param("id")
 	var warehouse models.Warehouse
-	if err := database.DB.First(&warehouse, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&warehouse, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
 		return
 	}
@@ -102,7 +111,7 @@ func UpdateWarehouse(c *gin.Context) {
 	warehouse.Location = input.Location
 	warehouse.Capacity = input.Capacity
 
-	if err := database.DB.Save(&warehouse).Error; err != nil {
+	if err := h.DB.WithContext(c).Save(&warehouse).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -121,15 +130,15 @@ func UpdateWarehouse(c *gin.Context) {
 // @Failure      500  {object}  gin.H
 // @Security     BearerAuth
 // @Router       /warehouses/{id} [delete]
-func DeleteWarehouse(c *gin.Context) {
+func (h *WarehouseHandler) DeleteWarehouse(c *gin.Context) {
 	id := c.Param("id")
 	var warehouse models.Warehouse
-	if err := database.DB.First(&warehouse, "id = ?", id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&warehouse, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&warehouse).Error; err != nil {
+	if err := h.DB.WithContext(c).Delete(&warehouse).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
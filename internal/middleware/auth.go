@@ -1,21 +1,50 @@
 package middleware
 
 import (
-	"fmt"
-	"go-rest/internal/database"
 	"go-rest/internal/models"
+	"go-rest/internal/services"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-var jwtSecret = []byte("your_secret_key") // Should match the one in handlers
-
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates a request by its Bearer access token or, for
+// machine clients, its X-API-Key header. Role permissions come from cache.
+func AuthMiddleware(sessions *services.SessionService, apiKeys *services.APIKeyService, db *gorm.DB, cache *services.PermissionCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			key, err := apiKeys.Authenticate(rawKey)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+
+			user, roles, ok := activeUser(c, db, cache, key.UserID)
+			if !ok {
+				return
+			}
+
+			c.Set("userID", key.UserID)
+			c.Set("apiKeyID", key.ID)
+
+			// A key can never do more than its owner currently can, so a
+			// pattern is kept only if the owner holds it at least as broadly
+			scoped := make(map[string]bool)
+			for _, p := range key.Permissions {
+				if services.HasPermission(roles.Permissions, p.Resource, p.Action) {
+					scoped[p.Resource+":"+p.Action] = true
+				}
+			}
+			setUser(c, user, roles, scoped)
+
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -30,66 +59,209 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := sessions.ParseAccessToken(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Parse user_id from claims
-			// We expect user_id to be a string (UUID)
-			if idStr, ok := claims["user_id"].(string); ok {
-				if uid, err := uuid.Parse(idStr); err == nil {
-					c.Set("userID", uid)
-
-					// Load User with Role and Permissions
-					var user models.User
-					if err := database.DB.Preload("Role.Permissions").First(&user, "id = ?", uid).Error; err == nil {
-						c.Set("user", user)
-						c.Set("role", user.Role.Name)
-
-						// Create a map of permissions for easy lookup
-						perms := make(map[string]bool)
-						for _, p := range user.Role.Permissions {
-							perms[p.Resource+":"+p.Action] = true
-						}
-						c.Set("permissions", perms)
-					}
-				}
-			}
-
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		// Tokens of a logged out or revoked session stop working immediately
+		if active, err := sessions.Active(claims.SessionID); err != nil || !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session revoked"})
 			c.Abort()
 			return
 		}
+
+		user, roles, ok := activeUser(c, db, cache, claims.UserID)
+		if !ok {
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("mfa", claims.MFA)
+		setUser(c, user, roles, roles.Permissions)
+
+		c.Next()
+	}
+}
+
+// activeUser loads the user a credential belongs to, rejecting the request
+// if they were deleted or disabled since it was issued.
+func activeUser(c *gin.Context, db *gorm.DB, cache *services.PermissionCache, userID uuid.UUID) (models.User, *effectiveRoles, bool) {
+	user, roles, err := loadUser(db, cache, userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		c.Abort()
+		return user, nil, false
 	}
+	if user.Disabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account disabled"})
+		c.Abort()
+		return user, nil, false
+	}
+	return user, roles, true
 }
 
+// loadUser loads a user with their roles and the union of the permissions
+// those roles grant. The user is always read fresh, so disabling takes
+// effect at once; which roles they hold, and what those grant, come from
+// cache.
+func loadUser(db *gorm.DB, cache *services.PermissionCache, userID uuid.UUID) (models.User, *effectiveRoles, error) {
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return user, nil, err
+	}
+
+	roleIDs, err := cache.UserRoleIDs(userID)
+	if err != nil {
+		return user, nil, err
+	}
+
+	effective := &effectiveRoles{Names: []string{}}
+	for _, id := range roleIDs {
+		role, err := cache.Role(id)
+		if err != nil {
+			return user, nil, err
+		}
+		user.Roles = append(user.Roles, role.Role)
+		effective.Names = append(effective.Names, role.Role.Name)
+		effective.RequireMFA = effective.RequireMFA || role.RequireMFA
+
+		// A single role's map is shared as is; only a union needs a copy
+		if effective.Permissions == nil {
+			effective.Permissions = role.Permissions
+		} else {
+			union := make(map[string]bool, len(effective.Permissions)+len(role.Permissions))
+			for name := range effective.Permissions {
+				union[name] = true
+			}
+			for name := range role.Permissions {
+				union[name] = true
+			}
+			effective.Permissions = union
+		}
+	}
+	if effective.Permissions == nil {
+		effective.Permissions = map[string]bool{}
+	}
+	return user, effective, nil
+}
+
+// effectiveRoles is what a user's roles add up to.
+type effectiveRoles struct {
+	Names       []string
+	Permissions map[string]bool
+	RequireMFA  bool
+}
+
+func setUser(c *gin.Context, user models.User, roles *effectiveRoles, perms map[string]bool) {
+	c.Set("user", user)
+	c.Set("roles", roles.Names)
+	c.Set("mfaRequired", roles.RequireMFA)
+	c.Set("permissions", perms)
+}
+
+// RequirePermission allows the request if any granted permission covers
+// resource:action; see services.HasPermission for the matching rules.
 func RequirePermission(resource, action string) gin.HandlerFunc {
+	return RequireAllPermissions(resource + ":" + action)
+}
+
+// RequireAnyPermission allows the request if at least one of the
+// "resource:action" permissions is granted.
+func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
+	required := parsePermissions(permissions)
 	return func(c *gin.Context) {
-		perms, exists := c.Get("permissions")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "No permissions found"})
-			c.Abort()
+		granted, ok := grantedPermissions(c)
+		if !ok {
 			return
 		}
 
-		permissions := perms.(map[string]bool)
-		if !permissions[resource+":"+action] && !permissions["*:*"] { // Check specific or superadmin
-			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		for _, p := range required {
+			if services.HasPermission(granted, p[0], p[1]) {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		c.Abort()
+	}
+}
+
+// RequireAllPermissions allows the request only if every one of the
+// "resource:action" permissions is granted.
+func RequireAllPermissions(permissions ...string) gin.HandlerFunc {
+	required := parsePermissions(permissions)
+	return func(c *gin.Context) {
+		granted, ok := grantedPermissions(c)
+		if !ok {
+			return
+		}
+
+		for _, p := range required {
+			if !services.HasPermission(granted, p[0], p[1]) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// grantedPermissions returns the permissions AuthMiddleware set, aborting
+// the request if there are none or the role's MFA requirement is not met.
+func grantedPermissions(c *gin.Context) (map[string]bool, bool) {
+	perms, exists := c.Get("permissions")
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No permissions found"})
+		c.Abort()
+		return nil, false
+	}
+
+	// Roles that require MFA grant nothing to a session without it. API
+	// keys never carry a second factor.
+	if c.GetBool("mfaRequired") && !c.GetBool("mfa") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "MFA required"})
+		c.Abort()
+		return nil, false
+	}
+
+	return perms.(map[string]bool), true
+}
+
+// parsePermissions splits "resource:action" names when routes are set up
+// and adds them to the catalog; a malformed name is a programming error.
+func parsePermissions(permissions []string) [][2]string {
+	parsed := make([][2]string, 0, len(permissions))
+	for _, name := range permissions {
+		resource, action, ok := strings.Cut(name, ":")
+		if !ok || services.ValidatePermission(resource, action) != nil {
+			panic("middleware: invalid permission " + name)
+		}
+		services.RegisterPermission(resource, action)
+		parsed = append(parsed, [2]string{resource, action})
+	}
+	return parsed
+}
+
+// ScopeWarehouses resolves which warehouses the authenticated user may
+// touch and stores it as "warehouseScope" for the handlers to apply. It
+// must run after AuthMiddleware.
+func ScopeWarehouses(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perms, _ := c.Get("permissions")
+		granted, _ := perms.(map[string]bool)
+
+		scope, err := services.ResolveWarehouseScope(db, c.MustGet("userID").(uuid.UUID), granted)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
+		c.Set("warehouseScope", scope)
 
 		c.Next()
 	}
@@ -0,0 +1,214 @@
+// Package mockoidc is a minimal OpenID Connect provider for trying single
+// sign-on locally and for testing it. It signs in a fixed user without asking
+// for credentials, so it must never be exposed.
+//
+// The sub, username, email, email_verified, groups and amr query parameters
+// on the authorize request override the defaults, to sign in as someone else.
+package mockoidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const keyID = "mock"
+
+type grant struct {
+	ClientID    string
+	RedirectURI string
+	Challenge   string
+	Nonce       string
+	Claims      jwt.MapClaims
+	ExpiresAt   time.Time
+}
+
+// Provider issues ID tokens for the user described by Defaults, or by the
+// authorize request's query parameters.
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Key          *rsa.PrivateKey
+	Defaults     url.Values
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+// New returns a provider with a fresh signing key. An empty clientSecret
+// accepts any secret.
+func New(issuer, clientID, clientSecret string, defaults url.Values) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Key:          key,
+		Defaults:     defaults,
+		codes:        make(map[string]grant),
+	}, nil
+}
+
+// Handler serves discovery, the authorize and token endpoints and the key set.
+func (p *Provider) Handler() http.Handler {
+	r := gin.New()
+	r.GET("/.well-known/openid-configuration", p.discovery)
+	r.GET("/authorize", p.authorize)
+	r.POST("/token", p.token)
+	r.GET("/jwks", p.jwks)
+	return r
+}
+
+func (p *Provider) discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *Provider) authorize(c *gin.Context) {
+	if c.Query("client_id") != p.ClientID || c.Query("response_type") != "code" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+	if c.Query("code_challenge") == "" || c.Query("code_challenge_method") != "S256" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "PKCE with S256 is required"})
+		return
+	}
+	redirect, err := url.Parse(c.Query("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "bad redirect_uri"})
+		return
+	}
+
+	param := func(name string) string {
+		if v := c.Query(name); v != "" {
+			return v
+		}
+		return p.Defaults.Get(name)
+	}
+	claims := jwt.MapClaims{
+		"sub":                param("sub"),
+		"preferred_username": param("username"),
+		"email":              param("email"),
+		"email_verified":     param("email") != "" && param("email_verified") != "false",
+		"groups":             split(param("groups")),
+		"amr":                split(param("amr")),
+	}
+
+	code := uuid.NewString()
+	p.mu.Lock()
+	p.codes[code] = grant{
+		ClientID:    p.ClientID,
+		RedirectURI: c.Query("redirect_uri"),
+		Challenge:   c.Query("code_challenge"),
+		Nonce:       c.Query("nonce"),
+		Claims:      claims,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", c.Query("state"))
+	redirect.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, redirect.String())
+}
+
+func (p *Provider) token(c *gin.Context) {
+	clientID, secret, hasBasic := c.Request.BasicAuth()
+	if hasBasic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID != p.ClientID || (p.ClientSecret != "" && secret != p.ClientSecret) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	g, ok := p.codes[c.PostForm("code")]
+	delete(p.codes, c.PostForm("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(c.PostForm("code_verifier")))
+	switch {
+	case c.PostForm("grant_type") != "authorization_code", !ok, time.Now().After(g.ExpiresAt):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant"})
+		return
+	case c.PostForm("redirect_uri") != g.RedirectURI:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "redirect_uri mismatch"})
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != g.Challenge:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.Issuer,
+		"aud":   g.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": g.Nonce,
+	}
+	for k, v := range g.Claims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": uuid.NewString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) jwks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": []gin.H{{
+		"kty": "RSA", "kid": keyID, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(p.Key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.Key.E)).Bytes()),
+	}}})
+}
+
+func split(v string) []string {
+	list := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a long-lived credential for machine-to-machine access. Only a
+// hash of the key is stored; the key itself is shown once at creation.
+type APIKey struct {
+	Base
+	UserID      uuid.UUID    `json:"user_id" gorm:"index"`
+	Name        string       `json:"name"`
+	Prefix      string       `json:"prefix"`
+	KeyHash     string       `json:"-" gorm:"uniqueIndex;size:64"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:api_key_permissions;"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time   `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time   `json:"revoked_at,omitempty"`
+}
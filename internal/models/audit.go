@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// AuditLog records a single create, update or delete of a stored entity.
+type AuditLog struct {
+	Base
+	ActorID    *uuid.UUID `json:"actor_id,omitempty" gorm:"index"` // nil for system and anonymous changes
+	Action     string     `json:"action" gorm:"index"`             // create, update or delete
+	EntityType string     `json:"entity_type" gorm:"index"`        // table name, e.g. items, role_permissions
+	EntityID   string     `json:"entity_id" gorm:"index"`
+	// Before and After hold the changed columns for an update, and the whole
+	// row for a delete or create respectively.
+	Before    map[string]interface{} `json:"before,omitempty" gorm:"serializer:json;type:text"`
+	After     map[string]interface{} `json:"after,omitempty" gorm:"serializer:json;type:text"`
+	RequestID string                 `json:"request_id" gorm:"index"`
+	IP        string                 `json:"ip"`
+}
@@ -20,3 +20,14 @@ func (base *Base) BeforeCreate(tx *gorm.DB) (err error) {
 	base.ID = uuid.New()
 	return
 }
+
+// Versioned adds an optimistic lock to a model. Every update bumps Version
+// and only succeeds if the row still has the version it was read with.
+type Versioned struct {
+	Version int `gorm:"not null;default:1" json:"version"`
+}
+
+// LockVersion returns the lock, so services can check and bump it.
+func (v *Versioned) LockVersion() *Versioned {
+	return v
+}
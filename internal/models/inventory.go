@@ -1,10 +1,45 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Inventory is the stock of one item in one warehouse. There is at most one
+// record per pair, deleted or not.
 type Inventory struct {
 	Base
-	ItemID      uuid.UUID `json:"item_id"`
-	WarehouseID uuid.UUID `json:"warehouse_id"`
-	Quantity    int       `json:"quantity"`
+	Versioned
+	ItemID      uuid.UUID `json:"item_id" gorm:"uniqueIndex:idx_inventories_stock"`
+	WarehouseID uuid.UUID `json:"warehouse_id" gorm:"uniqueIndex:idx_inventories_stock"`
+	Quantity    int       `json:"quantity"` // on hand
+	Reserved    int       `json:"reserved"` // held by active reservations
+	// Available is what can still be sold or reserved: on hand less reserved.
+	Available int `json:"available" gorm:"-"`
+}
+
+func (i *Inventory) AfterFind(tx *gorm.DB) error {
+	i.Available = i.Quantity - i.Reserved
+	return nil
+}
+
+func (i *Inventory) AfterSave(tx *gorm.DB) error {
+	i.Available = i.Quantity - i.Reserved
+	return nil
+}
+
+// Reservation holds stock in a warehouse for a pending order until it is
+// turned into a sale, released, or expires.
+type Reservation struct {
+	Base
+	ItemID      uuid.UUID  `json:"item_id" gorm:"index:idx_reservations_stock"`
+	WarehouseID uuid.UUID  `json:"warehouse_id" gorm:"index:idx_reservations_stock"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status" gorm:"index"` // active, fulfilled, released, expired
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"index"`
+	UserID      uuid.UUID  `json:"user_id"`
+	OrderID     *uuid.UUID `json:"order_id,omitempty"` // the order that fulfilled it
+	Note        string     `json:"note,omitempty"`
 }
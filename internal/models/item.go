@@ -4,6 +4,7 @@ import "github.com/google/uuid"
 
 type Item struct {
 	Base
+	Versioned
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
@@ -8,6 +8,7 @@ import (
 
 type PurchaseOrder struct {
 	Base
+	Versioned
 	SupplierID  uuid.UUID           `json:"supplier_id"`
 	WarehouseID uuid.UUID           `json:"warehouse_id"`
 	Status      string              `json:"status"` // Pending, Received, Cancelled
@@ -9,12 +9,20 @@ type Role struct {
 	Name        string       `json:"name" gorm:"unique"`
 	Description string       `json:"description"`
 	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+
+	// RequireMFA denies members every permission until they sign in with a
+	// second factor.
+	RequireMFA bool `json:"require_mfa"`
+
+	// ParentID is a role whose permissions and MFA requirement this role
+	// inherits.
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
 }
 
 type Permission struct {
 	Base
-	Resource string `json:"resource"` // e.g., items, users
-	Action   string `json:"action"`   // e.g., read, write, delete
+	Resource string `json:"resource"` // e.g., items, inventory.transfer, items.*, *
+	Action   string `json:"action"`   // e.g., read, write, delete, *
 }
 
 // Join table for Role <-> Permission
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginThrottle counts recent failed logins for a username or client IP.
+type LoginThrottle struct {
+	Base
+	Target        string     `json:"target" gorm:"uniqueIndex;size:255"` // "user:<name>" or "ip:<addr>"
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// SecurityEvent records an authentication event for later review.
+type SecurityEvent struct {
+	Base
+	Type     string     `json:"type" gorm:"index"` // e.g. login_failed, account_locked
+	UserID   *uuid.UUID `json:"user_id,omitempty" gorm:"index"`
+	Username string     `json:"username"`
+	IP       string     `json:"ip"`
+	Details  string     `json:"details"`
+}
+
+// PasswordReset is a single-use token that lets a user set a new password
+// without knowing the current one.
+type PasswordReset struct {
+	Base
+	UserID    uuid.UUID  `json:"user_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;size:64"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the
+// user has lost their authenticator.
+type RecoveryCode struct {
+	Base
+	UserID   uuid.UUID  `json:"user_id" gorm:"index"`
+	CodeHash string     `json:"-" gorm:"uniqueIndex;size:64"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
+
+// OIDCState is a single sign-on login in progress, kept until the identity
+// provider redirects back.
+type OIDCState struct {
+	Base
+	StateHash    string    `json:"-" gorm:"uniqueIndex;size:64"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
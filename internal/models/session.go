@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login and the family of refresh tokens rotated from it.
+// Revoking a session invalidates every access and refresh token issued for it.
+type Session struct {
+	Base
+	UserID    uuid.UUID  `json:"user_id" gorm:"index"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	UserAgent string     `json:"user_agent"`
+	IP        string     `json:"ip"`
+	MFA       bool       `json:"mfa"` // login confirmed with a second factor
+}
+
+type RefreshToken struct {
+	Base
+	SessionID    uuid.UUID  `json:"session_id" gorm:"index"`
+	UserID       uuid.UUID  `json:"user_id"`
+	TokenHash    string     `json:"-" gorm:"uniqueIndex;size:64"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockAdjustment is a manual change to a stock record, with a reason.
+// Small ones are applied when requested; larger ones stay pending until
+// approved or rejected.
+type StockAdjustment struct {
+	Base
+	InventoryID uuid.UUID `json:"inventory_id" gorm:"index"`
+	ItemID      uuid.UUID `json:"item_id"`
+	WarehouseID uuid.UUID `json:"warehouse_id" gorm:"index"`
+	Delta       int       `json:"delta"` // change in stock, negative when stock leaves
+	// CountedQuantity is the stock counted, when the delta was worked out
+	// from a count rather than given.
+	CountedQuantity *int `json:"counted_quantity,omitempty"`
+	// InventoryVersion is the version of the stock record when the
+	// adjustment was requested. A count can only be applied to stock that
+	// has not moved since.
+	InventoryVersion int        `json:"inventory_version"`
+	Reason           string     `json:"reason" gorm:"index"`
+	Note             string     `json:"note,omitempty"`
+	Status           string     `json:"status" gorm:"index"` // pending, applied, rejected
+	RequestedBy      uuid.UUID  `json:"requested_by"`
+	DecidedBy        *uuid.UUID `json:"decided_by,omitempty"` // who approved or rejected it
+	DecidedAt        *time.Time `json:"decided_at,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrStockMovementImmutable = errors.New("stock movements cannot be changed or deleted")
+
+// StockMovement is an entry in the append-only stock ledger. Every change to
+// an Inventory quantity writes one, so the sum of an item's movements in a
+// warehouse is its stock on hand.
+type StockMovement struct {
+	Base
+	ItemID        uuid.UUID  `json:"item_id" gorm:"index:idx_stock_movements_stock"`
+	WarehouseID   uuid.UUID  `json:"warehouse_id" gorm:"index:idx_stock_movements_stock"`
+	Type          string     `json:"type" gorm:"index"`        // receipt, sale, transfer_out, transfer_in, adjustment, return
+	Quantity      int        `json:"quantity"`                 // change in stock, negative when stock leaves
+	Balance       int        `json:"balance"`                  // stock on hand after the movement
+	ReferenceType string     `json:"reference_type,omitempty"` // order, purchase_order, transfer or stock_adjustment
+	ReferenceID   *uuid.UUID `json:"reference_id,omitempty" gorm:"index"`
+	UserID        *uuid.UUID `json:"user_id,omitempty"`
+	Note          string     `json:"note,omitempty"`
+}
+
+// BeforeUpdate keeps the ledger append-only.
+func (m *StockMovement) BeforeUpdate(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// BeforeDelete keeps the ledger append-only.
+func (m *StockMovement) BeforeDelete(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
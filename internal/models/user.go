@@ -1,11 +1,57 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
 	Base
-	Username string    `gorm:"unique" json:"username" form:"username"`
-	Password string    `json:"-" form:"password"`
-	RoleID   uuid.UUID `json:"role_id" form:"role_id"`
-	Role     Role      `json:"role"`
+	Username string  `gorm:"unique" json:"username" form:"username"`
+	Email    *string `gorm:"uniqueIndex;size:255" json:"email,omitempty" form:"email" binding:"omitempty,email"`
+	Password string  `json:"-" form:"password"`
+
+	// EmailVerifiedAt is set once the user has shown they receive mail at
+	// Email, by completing a password reset or by signing in through a
+	// provider that vouches for it. Changing the email clears it.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" form:"-"`
+
+	// Roles grant the user the union of their permissions, including those
+	// inherited from parent roles.
+	Roles []Role `json:"roles" form:"-" gorm:"many2many:user_roles;"`
+
+	// Warehouses limits the stock the user can see and move, unless their
+	// role may access every warehouse.
+	Warehouses []Warehouse `json:"warehouses,omitempty" form:"-" gorm:"many2many:user_warehouses;"`
+
+	// Disabled users cannot sign in, and their sessions and API keys stop
+	// working immediately.
+	Disabled bool `json:"disabled" form:"-"`
+
+	// MFAEnabled is set once the user has confirmed a TOTP code for
+	// MFASecret. MFALastStep is the last accepted time step, so a code
+	// cannot be replayed.
+	MFAEnabled  bool   `json:"mfa_enabled" form:"-"`
+	MFASecret   string `json:"-" form:"-"`
+	MFALastStep int64  `json:"-" form:"-"`
+
+	// OIDCIssuer and OIDCSubject link the user to a single sign-on identity.
+	OIDCIssuer  *string `json:"-" form:"-" gorm:"column:oidc_issuer;uniqueIndex:idx_users_oidc;size:255"`
+	OIDCSubject *string `json:"-" form:"-" gorm:"column:oidc_subject;uniqueIndex:idx_users_oidc;size:255"`
+}
+
+// AdminBootstrap records the first user, whose registration made them an
+// admin. Its ID is always 1, so of registrations racing to be first only
+// one can commit.
+type AdminBootstrap struct {
+	ID        int `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+// Join table for User <-> Role
+type UserRole struct {
+	UserID uuid.UUID `gorm:"primaryKey"`
+	RoleID uuid.UUID `gorm:"primaryKey"`
 }
@@ -1,8 +1,16 @@
 package models
 
+import "github.com/google/uuid"
+
 type Warehouse struct {
 	Base
 	Name     string `json:"name" form:"name"`
 	Location string `json:"location" form:"location"`
 	Capacity int    `json:"capacity" form:"capacity"`
 }
+
+// Join table for User <-> Warehouse
+type UserWarehouse struct {
+	UserID      uuid.UUID `gorm:"primaryKey"`
+	WarehouseID uuid.UUID `gorm:"primaryKey"`
+}
@@ -9,120 +9,205 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(r *gin.Engine) {
+// SetupRoutes registers every route. auth authenticates a request and
+// warehouseScope limits it to the user's warehouses.
+func SetupRoutes(r *gin.Engine, h *handlers.Handlers, auth, warehouseScope gin.HandlerFunc) {
 	api := r.Group("/api")
 	{
 		// Public routes
-		api.POST("/register", handlers.Register)
-		api.POST("/login", handlers.Login)
+		api.POST("/register", h.Auth.Register)
+		api.POST("/login", h.Auth.Login)
+		api.POST("/login/mfa", h.Auth.LoginMFA)
+		api.GET("/auth/oidc/login", h.Auth.OIDCLogin)
+		api.GET("/auth/oidc/callback", h.Auth.OIDCCallback)
+		api.POST("/token/refresh", h.Auth.RefreshToken)
+		api.POST("/logout", auth, h.Auth.Logout)
+		api.POST("/password/forgot", h.Auth.ForgotPassword)
+		api.POST("/password/reset", h.Auth.ResetPassword)
 
 		// Protected routes
+		// Current user
+		me := api.Group("/me")
+		me.Use(auth)
+		{
+			me.GET("", h.User.GetMe)
+			me.GET("/permissions", h.User.GetMyPermissions)
+			me.PUT("/password", h.Auth.ChangePassword)
+			me.POST("/mfa/enroll", h.MFA.EnrollMFA)
+			me.POST("/mfa/activate", h.MFA.ActivateMFA)
+			me.POST("/mfa/disable", h.MFA.DisableMFA)
+			me.POST("/mfa/recovery-codes", h.MFA.RegenerateRecoveryCodes)
+		}
+
+		// Users
+		users := api.Group("/users")
+		users.Use(auth)
+		{
+			users.POST("", middleware.RequirePermission("users", "write"), h.User.CreateUser)
+			users.GET("", middleware.RequirePermission("users", "read"), h.User.GetUsers)
+			users.GET("/:id", middleware.RequirePermission("users", "read"), h.User.GetUser)
+			users.GET("/:id/permissions", middleware.RequirePermission("users", "read"), h.User.GetUserPermissions)
+			users.PUT("/:id", middleware.RequirePermission("users", "write"), h.User.UpdateUser)
+			users.DELETE("/:id", middleware.RequirePermission("users", "delete"), h.User.DeleteUser)
+		}
+
+		// API Keys (self-service)
+		apiKeys := api.Group("/api-keys")
+		apiKeys.Use(auth)
+		{
+			apiKeys.POST("", h.APIKey.CreateAPIKey)
+			apiKeys.GET("", h.APIKey.GetAPIKeys)
+			apiKeys.DELETE("/:id", h.APIKey.RevokeAPIKey)
+		}
+
 		// Items
 		items := api.Group("/items")
-		items.Use(middleware.AuthMiddleware())
+		items.Use(auth)
 		{
-			items.POST("", middleware.RequirePermission("items", "write"), handlers.CreateItem)
-			items.GET("", middleware.RequirePermission("items", "read"), handlers.GetItems)
-			items.GET("/:id", middleware.RequirePermission("items", "read"), handlers.GetItem)
-			items.PUT("/:id", middleware.RequirePermission("items", "write"), handlers.UpdateItem)
-			items.DELETE("/:id", middleware.RequirePermission("items", "delete"), handlers.DeleteItem)
+			items.POST("", middleware.RequirePermission("items", "write"), h.Item.CreateItem)
+			items.GET("", middleware.RequirePermission("items", "read"), h.Item.GetItems)
+			items.GET("/:id", middleware.RequirePermission("items", "read"), h.Item.GetItem)
+			items.PUT("/:id", middleware.RequirePermission("items", "write"), h.Item.UpdateItem)
+			items.DELETE("/:id", middleware.RequirePermission("items", "delete"), h.Item.DeleteItem)
 
 			// Product Enhancements
-			items.POST("/:id/media", middleware.RequirePermission("items", "write"), handlers.UploadItemMedia)
-			items.POST("/:id/reviews", middleware.RequirePermission("reviews", "write"), handlers.CreateReview)
-			items.POST("/:id/favorite", middleware.RequirePermission("favorites", "write"), handlers.ToggleFavorite)
+			items.POST("/:id/media", middleware.RequirePermission("items", "write"), h.Media.UploadItemMedia)
+			items.POST("/:id/reviews", middleware.RequirePermission("reviews", "write"), h.Review.CreateReview)
+			items.POST("/:id/favorite", middleware.RequirePermission("favorites", "write"), h.Favorite.ToggleFavorite)
 		}
 
 		// Categories
 		categories := api.Group("/categories")
-		categories.Use(middleware.AuthMiddleware())
+		categories.Use(auth)
 		{
-			categories.POST("", middleware.RequirePermission("categories", "write"), handlers.CreateCategory)
-			categories.GET("", middleware.RequirePermission("categories", "read"), handlers.GetCategories)
-			categories.PUT("/:id", middleware.RequirePermission("categories", "write"), handlers.UpdateCategory)
-			categories.DELETE("/:id", middleware.RequirePermission("categories", "delete"), handlers.DeleteCategory)
+			categories.POST("", middleware.RequirePermission("categories", "write"), h.Category.CreateCategory)
+			categories.GET("", middleware.RequirePermission("categories", "read"), h.Category.GetCategories)
+			categories.PUT("/:id", middleware.RequirePermission("categories", "write"), h.Category.UpdateCategory)
+			categories.DELETE("/:id", middleware.RequirePermission("categories", "delete"), h.Category.DeleteCategory)
 		}
 
 		// Warehouses
 		warehouses := api.Group("/warehouses")
-		warehouses.Use(middleware.AuthMiddleware())
+		warehouses.Use(auth)
 		{
-			warehouses.POST("", middleware.RequirePermission("warehouses", "write"), handlers.CreateWarehouse)
-			warehouses.GET("", middleware.RequirePermission("warehouses", "read"), handlers.GetWarehouses)
-			warehouses.PUT("/:id", middleware.RequirePermission("warehouses", "write"), handlers.UpdateWarehouse)
-			warehouses.DELETE("/:id", middleware.RequirePermission("warehouses", "delete"), handlers.DeleteWarehouse)
+			warehouses.POST("", middleware.RequirePermission("warehouses", "write"), h.Warehouse.CreateWarehouse)
+			warehouses.GET("", middleware.RequirePermission("warehouses", "read"), h.Warehouse.GetWarehouses)
+			warehouses.PUT("/:id", middleware.RequirePermission("warehouses", "write"), h.Warehouse.UpdateWarehouse)
+			warehouses.DELETE("/:id", middleware.RequirePermission("warehouses", "delete"), h.Warehouse.DeleteWarehouse)
 		}
 
 		// Suppliers
 		suppliers := api.Group("/suppliers")
-		suppliers.Use(middleware.AuthMiddleware())
+		suppliers.Use(auth)
 		{
-			suppliers.POST("", middleware.RequirePermission("suppliers", "write"), handlers.CreateSupplier)
-			suppliers.GET("", middleware.RequirePermission("suppliers", "read"), handlers.GetSuppliers)
-			suppliers.PUT("/:id", middleware.RequirePermission("suppliers", "write"), handlers.UpdateSupplier)
-			suppliers.DELETE("/:id", middleware.RequirePermission("suppliers", "delete"), handlers.DeleteSupplier)
+			suppliers.POST("", middleware.RequirePermission("suppliers", "write"), h.Supplier.CreateSupplier)
+			suppliers.GET("", middleware.RequirePermission("suppliers", "read"), h.Supplier.GetSuppliers)
+			suppliers.PUT("/:id", middleware.RequirePermission("suppliers", "write"), h.Supplier.UpdateSupplier)
+			suppliers.DELETE("/:id", middleware.RequirePermission("suppliers", "delete"), h.Supplier.DeleteSupplier)
 		}
 
 		// Discounts
 		discounts := api.Group("/discounts")
-		discounts.Use(middleware.AuthMiddleware())
+		discounts.Use(auth)
 		{
-			discounts.POST("", middleware.RequirePermission("discounts", "write"), handlers.CreateDiscount)
-			discounts.GET("", middleware.RequirePermission("discounts", "read"), handlers.GetDiscounts)
-			discounts.PUT("/:id", middleware.RequirePermission("discounts", "write"), handlers.UpdateDiscount)
-			discounts.DELETE("/:id", middleware.RequirePermission("discounts", "delete"), handlers.DeleteDiscount)
+			discounts.POST("", middleware.RequirePermission("discounts", "write"), h.Discount.CreateDiscount)
+			discounts.GET("", middleware.RequirePermission("discounts", "read"), h.Discount.GetDiscounts)
+			discounts.PUT("/:id", middleware.RequirePermission("discounts", "write"), h.Discount.UpdateDiscount)
+			discounts.DELETE("/:id", middleware.RequirePermission("discounts", "delete"), h.Discount.DeleteDiscount)
 		}
 
 		// Inventory
 		inventory := api.Group("/inventory")
-		inventory.Use(middleware.AuthMiddleware())
+		inventory.Use(auth, warehouseScope)
 		{
-			inventory.GET("", middleware.RequirePermission("inventory", "read"), handlers.GetInventory)
-			inventory.POST("/add", middleware.RequirePermission("inventory", "write"), handlers.AddStock)
-			inventory.POST("/transfer", middleware.RequirePermission("inventory", "write"), handlers.TransferStock)
-			inventory.PUT("/:id", middleware.RequirePermission("inventory", "write"), handlers.UpdateInventory)
-			inventory.DELETE("/:id", middleware.RequirePermission("inventory", "delete"), handlers.DeleteInventory)
+			inventory.GET("", middleware.RequirePermission("inventory", "read"), h.Inventory.GetInventory)
+			inventory.GET("/movements", middleware.RequirePermission("inventory", "read"), h.Inventory.GetStockMovements)
+			inventory.GET("/ledger/check", middleware.RequirePermission("inventory", "read"), h.Inventory.CheckLedger)
+			inventory.POST("/add", middleware.RequirePermission("inventory", "write"), h.Inventory.AddStock)
+			inventory.POST("/transfer", middleware.RequirePermission("inventory.transfer", "write"), h.Inventory.TransferStock)
+			inventory.GET("/reservations", middleware.RequirePermission("inventory", "read"), h.Inventory.GetReservations)
+			inventory.GET("/reservations/:id", middleware.RequirePermission("inventory", "read"), h.Inventory.GetReservation)
+			inventory.POST("/reservations", middleware.RequirePermission("inventory", "write"), h.Inventory.CreateReservation)
+			inventory.DELETE("/reservations/:id", middleware.RequirePermission("inventory", "write"), h.Inventory.ReleaseReservation)
+			inventory.GET("/adjustments", middleware.RequirePermission("inventory", "read"), h.Adjustment.GetAdjustments)
+			inventory.GET("/adjustments/reasons", middleware.RequirePermission("inventory", "read"), h.Adjustment.GetAdjustmentReasons)
+			inventory.GET("/adjustments/:id", middleware.RequirePermission("inventory", "read"), h.Adjustment.GetAdjustment)
+			inventory.POST("/adjustments", middleware.RequirePermission("inventory", "write"), h.Adjustment.CreateAdjustment)
+			inventory.POST("/adjustments/:id/approve", middleware.RequirePermission("inventory", "approve"), h.Adjustment.ApproveAdjustment)
+			inventory.POST("/adjustments/:id/reject", middleware.RequirePermission("inventory", "approve"), h.Adjustment.RejectAdjustment)
+			inventory.PUT("/:id", middleware.RequirePermission("inventory", "write"), h.Adjustment.UpdateInventory)
+			inventory.DELETE("/:id", middleware.RequirePermission("inventory", "delete"), h.Inventory.DeleteInventory)
 		}
 
 		// Purchase Orders
 		pos := api.Group("/purchase-orders")
-		pos.Use(middleware.AuthMiddleware())
+		pos.Use(auth, warehouseScope)
 		{
-			pos.POST("", middleware.RequirePermission("purchase_orders", "write"), handlers.CreatePurchaseOrder)
-			pos.GET("", middleware.RequirePermission("purchase_orders", "read"), handlers.GetPurchaseOrders)
-			pos.PUT("/:id/status", middleware.RequirePermission("purchase_orders", "write"), handlers.UpdatePurchaseOrderStatus)
-			pos.DELETE("/:id", middleware.RequirePermission("purchase_orders", "delete"), handlers.DeletePurchaseOrder)
+			pos.POST("", middleware.RequirePermission("purchase_orders", "write"), h.PurchaseOrder.CreatePurchaseOrder)
+			pos.GET("", middleware.RequirePermission("purchase_orders", "read"), h.PurchaseOrder.GetPurchaseOrders)
+			pos.PUT("/:id/status", middleware.RequirePermission("purchase_orders", "write"), h.PurchaseOrder.UpdatePurchaseOrderStatus)
+			pos.DELETE("/:id", middleware.RequirePermission("purchase_orders", "delete"), h.PurchaseOrder.DeletePurchaseOrder)
 		}
 
 		// Sales Orders
 		orders := api.Group("/orders")
-		orders.Use(middleware.AuthMiddleware())
+		orders.Use(auth, warehouseScope)
 		{
-			orders.POST("", middleware.RequirePermission("orders", "write"), handlers.CreateOrder)
+			orders.POST("", middleware.RequirePermission("orders", "write"), h.Order.CreateOrder)
 		}
 
 		// Reports & Dashboard
 		reports := api.Group("/reports")
-		reports.Use(middleware.AuthMiddleware())
+		reports.Use(auth, warehouseScope)
+		{
+			reports.GET("/financial", middleware.RequirePermission("reports", "read"), h.Report.GetFinancialReport)
+			reports.GET("/sales", middleware.RequirePermission("reports", "read"), h.Report.GetSalesReport)
+			reports.GET("/dashboard", middleware.RequirePermission("reports", "read"), h.Dashboard.GetDashboardSummary)
+		}
+
+		// Account Security
+		security := api.Group("/security")
+		security.Use(auth)
 		{
-			reports.GET("/financial", middleware.RequirePermission("reports", "read"), handlers.GetFinancialReport)
-			reports.GET("/sales", middleware.RequirePermission("reports", "read"), handlers.GetSalesReport)
-			reports.GET("/dashboard", middleware.RequirePermission("reports", "read"), handlers.GetDashboardSummary)
+			security.GET("/events", middleware.RequirePermission("security", "read"), h.Security.GetSecurityEvents)
+			security.POST("/users/:id/unlock", middleware.RequirePermission("users", "write"), h.Security.UnlockUser)
+		}
+
+		// Audit Log
+		audit := api.Group("/audit")
+		audit.Use(auth)
+		{
+			audit.GET("", middleware.RequirePermission("audit", "read"), h.Audit.GetAuditLogs)
 		}
 
 		// RBAC Management
 		rbac := api.Group("/rbac")
-		rbac.Use(middleware.AuthMiddleware())
+		rbac.Use(auth)
 		{
-			rbac.POST("/roles", middleware.RequirePermission("roles", "write"), handlers.CreateRole)
-			rbac.GET("/roles", middleware.RequirePermission("roles", "read"), handlers.GetRoles)
-			rbac.POST("/permissions", middleware.RequirePermission("roles", "write"), handlers.CreatePermission)
-			rbac.GET("/permissions", middleware.RequirePermission("roles", "read"), handlers.GetPermissions)
-			rbac.POST("/roles/:id/permissions", middleware.RequirePermission("roles", "write"), handlers.AssignPermissionsToRole)
-			rbac.POST("/users/:id/role", middleware.RequirePermission("roles", "write"), handlers.AssignRoleToUser)
+			rbac.POST("/roles", middleware.RequirePermission("roles", "write"), h.Role.CreateRole)
+			rbac.GET("/roles", middleware.RequirePermission("roles", "read"), h.Role.GetRoles)
+			rbac.GET("/roles/:id", middleware.RequirePermission("roles", "read"), h.Role.GetRole)
+			rbac.PUT("/roles/:id", middleware.RequirePermission("roles", "write"), h.Role.UpdateRole)
+			rbac.DELETE("/roles/:id", middleware.RequirePermission("roles", "delete"), h.Role.DeleteRole)
+			rbac.POST("/permissions", middleware.RequirePermission("roles", "write"), h.Role.CreatePermission)
+			rbac.GET("/permissions", middleware.RequirePermission("roles", "read"), h.Role.GetPermissions)
+			rbac.GET("/permissions/catalog", middleware.RequirePermission("roles", "read"), h.Role.GetPermissionCatalog)
+			rbac.GET("/permissions/:id", middleware.RequirePermission("roles", "read"), h.Role.GetPermission)
+			rbac.PUT("/permissions/:id", middleware.RequirePermission("roles", "write"), h.Role.UpdatePermission)
+			rbac.DELETE("/permissions/:id", middleware.RequirePermission("roles", "delete"), h.Role.DeletePermission)
+			rbac.POST("/roles/:id/permissions", middleware.RequirePermission("roles", "write"), h.Role.AssignPermissionsToRole)
+			rbac.PUT("/roles/:id/mfa", middleware.RequirePermission("roles", "write"), h.Role.SetRoleMFA)
+			rbac.PUT("/roles/:id/parent", middleware.RequirePermission("roles", "write"), h.Role.SetRoleParent)
+			rbac.POST("/users/:id/role", middleware.RequirePermission("roles", "write"), h.Role.SetUserRole)
+			rbac.POST("/users/:id/roles", middleware.RequirePermission("roles", "write"), h.Role.AssignRoleToUser)
+			rbac.DELETE("/users/:id/roles/:role_id", middleware.RequirePermission("roles", "write"), h.Role.RemoveRoleFromUser)
+			rbac.GET("/cache", middleware.RequirePermission("roles", "read"), h.Role.GetPermissionCacheStats)
 		}
 	}
 
+	// Public keys for verifying our tokens
+	r.GET("/.well-known/jwks.json", h.Auth.JWKS)
+
 	// Health check
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "pong"})
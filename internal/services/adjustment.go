@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Stock adjustment statuses.
+const (
+	AdjustmentPending  = "pending"
+	AdjustmentApplied  = "applied"
+	AdjustmentRejected = "rejected"
+)
+
+var (
+	ErrInvalidReason     = errors.New("unknown adjustment reason")
+	ErrInvalidAdjustment = errors.New("give either a non-zero delta or a counted quantity of at least zero")
+	ErrAdjustmentDecided = errors.New("adjustment has already been approved or rejected")
+	ErrSelfApproval      = errors.New("an adjustment must be approved by someone other than who requested it")
+	ErrCountOutdated     = errors.New("stock has moved since it was counted; count it again")
+)
+
+// AdjustmentInput is a requested stock adjustment. It gives either Delta
+// or CountedQuantity, from which the delta is worked out against the stock
+// on hand when it is requested. A count that needs approval is only applied
+// if the stock has not moved in the meantime.
+type AdjustmentInput struct {
+	Delta           *int
+	CountedQuantity *int
+	Reason          string
+	Note            string
+	// Version, when not zero, is the version of the stock record the
+	// request was based on; the adjustment fails if it has changed since.
+	Version int
+}
+
+// AdjustmentService manages manual stock adjustments. With a Scope it only
+// touches stock in the scope's warehouses.
+type AdjustmentService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+	Config    config.Adjustments
+	Scope     *WarehouseScope
+}
+
+func NewAdjustmentService(db *gorm.DB, inventory *InventoryService, cfg config.Adjustments) *AdjustmentService {
+	return &AdjustmentService{DB: db, Inventory: inventory, Config: cfg}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx.
+func (s *AdjustmentService) WithContext(ctx context.Context) *AdjustmentService {
+	return &AdjustmentService{DB: s.DB.WithContext(ctx), Inventory: s.Inventory, Config: s.Config, Scope: s.Scope}
+}
+
+// WithTx returns a copy of the service that runs inside tx.
+func (s *AdjustmentService) WithTx(tx *gorm.DB) *AdjustmentService {
+	return &AdjustmentService{DB: tx, Inventory: s.Inventory, Config: s.Config, Scope: s.Scope}
+}
+
+// WithScope returns a copy of the service limited to scope.
+func (s *AdjustmentService) WithScope(scope *WarehouseScope) *AdjustmentService {
+	return &AdjustmentService{DB: s.DB, Inventory: s.Inventory, Config: s.Config, Scope: scope}
+}
+
+// Request records an adjustment to a stock record by userID. It is applied
+// straight away unless it changes stock by more than the approval
+// threshold, in which case it stays pending.
+func (s *AdjustmentService) Request(inventoryID uuid.UUID, input AdjustmentInput, userID uuid.UUID) (*models.StockAdjustment, error) {
+	if !slices.Contains(s.Config.Reasons, input.Reason) {
+		return nil, ErrInvalidReason
+	}
+	if (input.Delta == nil) == (input.CountedQuantity == nil) ||
+		(input.Delta != nil && *input.Delta == 0) ||
+		(input.CountedQuantity != nil && *input.CountedQuantity < 0) {
+		return nil, ErrInvalidAdjustment
+	}
+
+	var adjustment *models.StockAdjustment
+	err := retryOnConflict(func() error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			inventory, err := s.Inventory.WithTx(tx).WithScope(s.Scope).Get(inventoryID)
+			if err != nil {
+				return err
+			}
+			if err := checkVersion(inventory, input.Version); err != nil {
+				return err
+			}
+
+			adjustment = &models.StockAdjustment{
+				InventoryID:      inventory.ID,
+				ItemID:           inventory.ItemID,
+				WarehouseID:      inventory.WarehouseID,
+				CountedQuantity:  input.CountedQuantity,
+				InventoryVersion: inventory.Version,
+				Reason:           input.Reason,
+				Note:             input.Note,
+				Status:           AdjustmentPending,
+				RequestedBy:      userID,
+			}
+			if input.Delta != nil {
+				adjustment.Delta = *input.Delta
+			} else {
+				adjustment.Delta = *input.CountedQuantity - inventory.Quantity
+			}
+			if err := tx.Create(adjustment).Error; err != nil {
+				return err
+			}
+
+			if abs(adjustment.Delta) > s.Config.ApprovalThreshold {
+				return nil
+			}
+			return s.apply(tx, adjustment, inventory, nil)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// Get returns an adjustment. Adjustments outside the scope are not found.
+func (s *AdjustmentService) Get(id uuid.UUID) (*models.StockAdjustment, error) {
+	var adjustment models.StockAdjustment
+	err := s.DB.Scopes(s.Scope.Filter("warehouse_id")).First(&adjustment, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// Approve applies a pending adjustment on behalf of approverID, who may not
+// be the user who requested it. A count fails with ErrCountOutdated if the
+// stock has moved since it was counted, since its delta no longer holds.
+func (s *AdjustmentService) Approve(id, approverID uuid.UUID) (*models.StockAdjustment, error) {
+	var adjustment *models.StockAdjustment
+	err := retryOnConflict(func() error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if adjustment, err = s.pending(tx, id); err != nil {
+				return err
+			}
+			if adjustment.RequestedBy == approverID {
+				return ErrSelfApproval
+			}
+			inventory, err := s.Inventory.WithTx(tx).WithScope(s.Scope).Get(adjustment.InventoryID)
+			if err != nil {
+				return err
+			}
+			if adjustment.CountedQuantity != nil && checkVersion(inventory, adjustment.InventoryVersion) != nil {
+				return ErrCountOutdated
+			}
+			return s.apply(tx, adjustment, inventory, &approverID)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// Reject closes a pending adjustment without changing stock.
+func (s *AdjustmentService) Reject(id, approverID uuid.UUID) (*models.StockAdjustment, error) {
+	var adjustment *models.StockAdjustment
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if adjustment, err = s.pending(tx, id); err != nil {
+			return err
+		}
+		return decide(tx, adjustment, AdjustmentRejected, &approverID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// pending loads an adjustment that is still waiting for a decision.
+func (s *AdjustmentService) pending(tx *gorm.DB, id uuid.UUID) (*models.StockAdjustment, error) {
+	adjustment, err := s.WithTx(tx).Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.Status != AdjustmentPending {
+		return nil, ErrAdjustmentDecided
+	}
+	return adjustment, nil
+}
+
+// apply marks an adjustment applied and moves its delta into stock, as a
+// ledger adjustment by the user who requested it.
+func (s *AdjustmentService) apply(tx *gorm.DB, adjustment *models.StockAdjustment, inventory *models.Inventory, approverID *uuid.UUID) error {
+	// Reserved stock cannot be written off until its reservation ends
+	if adjustment.Delta < 0 && inventory.Available < -adjustment.Delta {
+		return ErrInsufficientStock
+	}
+	if err := decide(tx, adjustment, AdjustmentApplied, approverID); err != nil {
+		return err
+	}
+	if adjustment.Delta == 0 {
+		return nil // a count that matched the stock on hand
+	}
+
+	note := adjustment.Reason
+	if adjustment.Note != "" {
+		note += ": " + adjustment.Note
+	}
+	return s.Inventory.WithTx(tx).move(inventory, adjustment.Delta, Movement{
+		Type:          MovementAdjustment,
+		ReferenceType: ReferenceAdjustment,
+		ReferenceID:   &adjustment.ID,
+		UserID:        &adjustment.RequestedBy,
+		Note:          note,
+	})
+}
+
+// decide closes a pending adjustment with status. Of two requests deciding
+// the same adjustment, the second fails with ErrAdjustmentDecided.
+func decide(tx *gorm.DB, adjustment *models.StockAdjustment, status string, userID *uuid.UUID) error {
+	now := time.Now()
+	result := tx.Model(adjustment).Where("status = ?", AdjustmentPending).
+		Updates(map[string]interface{}{"status": status, "decided_by": userID, "decided_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAdjustmentDecided
+	}
+	adjustment.Status, adjustment.DecidedBy, adjustment.DecidedAt = status, userID, &now
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
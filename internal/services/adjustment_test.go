@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"go-rest/internal/config"
+)
+
+func TestApproveRefusesTheRequester(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	clerk := createUser(t, db, "clerk")
+	manager := createUser(t, db, "manager")
+	inventory := receiveStock(t, db, 100)
+
+	cfg := config.Default().Adjustments
+	adjustments := NewAdjustmentService(db, NewInventoryService(db), cfg)
+	delta := -(cfg.ApprovalThreshold + 1)
+	adjustment, err := adjustments.Request(inventory.ID, AdjustmentInput{Delta: &delta, Reason: "theft"}, clerk.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adjustment.Status != AdjustmentPending {
+		t.Fatalf("status = %s, want %s", adjustment.Status, AdjustmentPending)
+	}
+
+	if _, err := adjustments.Approve(adjustment.ID, clerk.ID); !errors.Is(err, ErrSelfApproval) {
+		t.Fatalf("approving one's own adjustment: err = %v, want ErrSelfApproval", err)
+	}
+	if adjustment, err = adjustments.Approve(adjustment.ID, manager.ID); err != nil {
+		t.Fatal(err)
+	}
+	if adjustment.Status != AdjustmentApplied {
+		t.Errorf("status = %s, want %s", adjustment.Status, AdjustmentApplied)
+	}
+}
+
+func TestApproveRefusesAnOutdatedCount(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	clerk := createUser(t, db, "clerk")
+	manager := createUser(t, db, "manager")
+	stock := receiveStock(t, db, 100)
+
+	cfg := config.Default().Adjustments
+	inventory := NewInventoryService(db)
+	adjustments := NewAdjustmentService(db, inventory, cfg)
+	counted := 100 - (cfg.ApprovalThreshold + 1)
+	stale, err := adjustments.Request(stock.ID, AdjustmentInput{CountedQuantity: &counted, Reason: "theft"}, clerk.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 1, Movement{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adjustments.Approve(stale.ID, manager.ID); !errors.Is(err, ErrCountOutdated) {
+		t.Fatalf("approving a count of stock that has moved: err = %v, want ErrCountOutdated", err)
+	}
+
+	counted--
+	fresh, err := adjustments.Request(stock.ID, AdjustmentInput{CountedQuantity: &counted, Reason: "theft"}, clerk.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adjustments.Approve(fresh.ID, manager.ID); err != nil {
+		t.Fatal(err)
+	}
+	after, err := inventory.Get(stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Quantity != counted {
+		t.Errorf("quantity after the count = %d, want %d", after.Quantity, counted)
+	}
+	checkLedger(t, db)
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-rest/internal/models"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Audit actions.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// auditSkippedTables are never audited: the log itself, and authentication
+// bookkeeping that the security event log already covers.
+var auditSkippedTables = map[string]bool{
+	"audit_logs":        true,
+	"schema_migrations": true,
+	"sessions":          true,
+	"refresh_tokens":    true,
+	"login_throttles":   true,
+	"security_events":   true,
+	"password_resets":   true,
+	"recovery_codes":    true,
+	"oidc_states":       true,
+}
+
+// auditIgnoredColumns change as a side effect of other writes. An update
+// that changes nothing else is not logged.
+var auditIgnoredColumns = map[string]bool{
+	"updated_at":   true,
+	"last_used_at": true,
+}
+
+const auditBeforeKey = "audit:before"
+
+// RegisterAuditCallbacks records every create, update and delete made
+// through a model in the audit log, inside the same transaction, so a change
+// is never stored without its entry. The actor, request ID and client IP are
+// read from the statement's context; handlers pass their gin.Context, which
+// carries the userID and requestID keys. Raw SQL is not audited.
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	const commit = "gorm:commit_or_rollback_transaction"
+	cb := db.Callback()
+
+	if err := cb.Create().After("gorm:create").Before(commit).Register("audit:create", auditCreated); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", auditLoadBefore); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Before(commit).Register("audit:update", auditUpdated); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", auditLoadBefore); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Before(commit).Register("audit:delete", auditDeleted)
+}
+
+func audited(db *gorm.DB) bool {
+	return db.Error == nil && db.Statement.Schema != nil && !auditSkippedTables[db.Statement.Schema.Table]
+}
+
+func auditCreated(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	var entries []models.AuditLog
+	for _, row := range auditRows(db.Statement.ReflectValue) {
+		entries = append(entries, newAuditLog(db, AuditCreate, row, nil, auditSnapshot(db, row)))
+	}
+	saveAuditLogs(db, entries)
+}
+
+// auditLoadBefore remembers the rows an update or delete is about to touch.
+func auditLoadBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	rows, err := auditAffected(db)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+func auditUpdated(db *gorm.DB) {
+	before, ok := auditBefore(db)
+	if !ok || len(before) == 0 {
+		return
+	}
+
+	after, err := auditReload(db, before)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	var entries []models.AuditLog
+	for i, row := range before {
+		old := auditSnapshot(db, row)
+		changedFrom, changedTo := auditDiff(old, auditSnapshot(db, after[i]))
+		if len(changedTo) == 0 {
+			continue
+		}
+		entries = append(entries, newAuditLog(db, AuditUpdate, row, changedFrom, changedTo))
+	}
+	saveAuditLogs(db, entries)
+}
+
+func auditDeleted(db *gorm.DB) {
+	before, ok := auditBefore(db)
+	if !ok {
+		return
+	}
+
+	var entries []models.AuditLog
+	for _, row := range before {
+		entries = append(entries, newAuditLog(db, AuditDelete, row, auditSnapshot(db, row), nil))
+	}
+	saveAuditLogs(db, entries)
+}
+
+func auditBefore(db *gorm.DB) ([]reflect.Value, bool) {
+	if !audited(db) {
+		return nil, false
+	}
+	rows, ok := db.InstanceGet(auditBeforeKey)
+	if !ok {
+		return nil, false
+	}
+	return rows.([]reflect.Value), true
+}
+
+// auditSession runs a query on the statement's connection, so it sees the
+// surrounding transaction, without re-entering the audit callbacks' state.
+func auditSession(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+}
+
+// auditAffected loads the rows matched by the statement's conditions and,
+// when it was given a model with a primary key, that row.
+func auditAffected(db *gorm.DB) ([]reflect.Value, error) {
+	stmt := db.Statement
+	query := auditSession(db).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if stmt.Unscoped {
+		query = query.Unscoped()
+	}
+
+	conditions := false
+	if where, ok := stmt.Clauses["WHERE"]; ok {
+		if expr, ok := where.Expression.(clause.Where); ok && len(expr.Exprs) > 0 {
+			query = query.Where(clause.And(expr.Exprs...))
+			conditions = true
+		}
+	}
+	if keys := auditRows(stmt.ReflectValue); len(keys) > 0 && auditHasPrimaryKey(db, keys[0]) {
+		query = query.Where(auditKeyCondition(db, keys))
+		conditions = true
+	}
+	if !conditions {
+		// GORM refuses updates and deletes without conditions
+		return nil, nil
+	}
+
+	return auditFind(query, stmt.Schema)
+}
+
+// auditReload loads rows again by primary key, in the same order.
+func auditReload(db *gorm.DB, rows []reflect.Value) ([]reflect.Value, error) {
+	stmt := db.Statement
+	query := auditSession(db).Unscoped().Model(reflect.New(stmt.Schema.ModelType).Interface())
+	found, err := auditFind(query.Where(auditKeyCondition(db, rows)), stmt.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]reflect.Value, len(found))
+	for _, row := range found {
+		byKey[auditEntityID(db, row)] = row
+	}
+	reloaded := make([]reflect.Value, len(rows))
+	for i, row := range rows {
+		if match, ok := byKey[auditEntityID(db, row)]; ok {
+			reloaded[i] = match
+		} else {
+			reloaded[i] = row // the update moved it out of reach; report no change
+		}
+	}
+	return reloaded, nil
+}
+
+func auditFind(query *gorm.DB, sch *schema.Schema) ([]reflect.Value, error) {
+	dest := reflect.New(reflect.SliceOf(sch.ModelType))
+	if err := query.Find(dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+	return auditRows(dest.Elem()), nil
+}
+
+// auditRows returns the structs held by a statement's reflect value.
+func auditRows(value reflect.Value) []reflect.Value {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{value}
+	case reflect.Slice, reflect.Array:
+		rows := make([]reflect.Value, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if row := reflect.Indirect(value.Index(i)); row.Kind() == reflect.Struct {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	return nil
+}
+
+func auditHasPrimaryKey(db *gorm.DB, row reflect.Value) bool {
+	fields := db.Statement.Schema.PrimaryFields
+	if len(fields) == 0 {
+		return false
+	}
+	for _, field := range fields {
+		if _, zero := field.ValueOf(db.Statement.Context, row); zero {
+			return false
+		}
+	}
+	return true
+}
+
+// auditKeyCondition matches rows by primary key, which may be composite.
+func auditKeyCondition(db *gorm.DB, rows []reflect.Value) clause.Expression {
+	sch := db.Statement.Schema
+	matches := make([]clause.Expression, 0, len(rows))
+	for _, row := range rows {
+		columns := make([]clause.Expression, 0, len(sch.PrimaryFields))
+		for _, field := range sch.PrimaryFields {
+			value, _ := field.ValueOf(db.Statement.Context, row)
+			columns = append(columns, clause.Eq{Column: clause.Column{Table: sch.Table, Name: field.DBName}, Value: value})
+		}
+		matches = append(matches, clause.And(columns...))
+	}
+	return clause.Or(matches...)
+}
+
+func auditEntityID(db *gorm.DB, row reflect.Value) string {
+	keys := make([]string, 0, len(db.Statement.Schema.PrimaryFields))
+	for _, field := range db.Statement.Schema.PrimaryFields {
+		value, _ := field.ValueOf(db.Statement.Context, row)
+		keys = append(keys, fmt.Sprint(value))
+	}
+	return strings.Join(keys, "/")
+}
+
+// auditSnapshot returns a row's columns as JSON values, leaving out those
+// hidden from the API such as password hashes.
+func auditSnapshot(db *gorm.DB, row reflect.Value) map[string]interface{} {
+	snapshot := make(map[string]interface{})
+	for _, field := range db.Statement.Schema.Fields {
+		if field.DBName == "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		value, _ := field.ValueOf(db.Statement.Context, row)
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		snapshot[field.DBName] = json.RawMessage(encoded)
+	}
+	return snapshot
+}
+
+// auditDiff returns the old and new values of every changed column.
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	from := make(map[string]interface{})
+	to := make(map[string]interface{})
+	for column, value := range after {
+		if auditIgnoredColumns[column] {
+			continue
+		}
+		old, _ := before[column].(json.RawMessage)
+		if !bytes.Equal(old, value.(json.RawMessage)) {
+			from[column], to[column] = before[column], value
+		}
+	}
+	return from, to
+}
+
+func newAuditLog(db *gorm.DB, action string, row reflect.Value, before, after map[string]interface{}) models.AuditLog {
+	entry := models.AuditLog{
+		Action:     action,
+		EntityType: db.Statement.Schema.Table,
+		EntityID:   auditEntityID(db, row),
+		Before:     before,
+		After:      after,
+	}
+
+	ctx := db.Statement.Context
+	if actorID, ok := ctx.Value("userID").(uuid.UUID); ok {
+		entry.ActorID = &actorID
+	}
+	entry.RequestID, _ = ctx.Value("requestID").(string)
+	if client, ok := ctx.(interface{ ClientIP() string }); ok {
+		entry.IP = client.ClientIP()
+	}
+	return entry
+}
+
+func saveAuditLogs(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&entries).Error; err != nil {
+		db.AddError(fmt.Errorf("audit log: %w", err))
+	}
+}
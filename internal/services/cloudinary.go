@@ -2,14 +2,22 @@ package services
 
 import (
 	"context"
-	"os"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
-func UploadToCloudinary(file interface{}, folder string) (string, string, error) {
-	cld, err := cloudinary.NewFromURL(os.Getenv("CLOUDINARY_URL"))
+// Cloudinary uploads media files to the configured Cloudinary account.
+type Cloudinary struct {
+	URL string
+}
+
+func NewCloudinary(url string) *Cloudinary {
+	return &Cloudinary{URL: url}
+}
+
+func (s *Cloudinary) Upload(file interface{}, folder string) (string, string, error) {
+	cld, err := cloudinary.NewFromURL(s.URL)
 	if err != nil {
 		return "", "", err
 	}
@@ -0,0 +1,11 @@
+package services
+
+import "errors"
+
+var (
+	ErrNotFound          = errors.New("record not found")
+	ErrInvalidQuantity   = errors.New("quantity must be positive")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrConflict          = errors.New("record was changed by another request; reload it and try again")
+)
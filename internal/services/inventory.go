@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/models"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Stock movement types.
+const (
+	MovementReceipt     = "receipt"
+	MovementSale        = "sale"
+	MovementTransferOut = "transfer_out"
+	MovementTransferIn  = "transfer_in"
+	MovementAdjustment  = "adjustment"
+	MovementReturn      = "return"
+)
+
+// What a stock movement's ReferenceID points at.
+const (
+	ReferenceOrder         = "order"
+	ReferencePurchaseOrder = "purchase_order"
+	ReferenceTransfer      = "transfer"
+	ReferenceAdjustment    = "stock_adjustment"
+)
+
+var (
+	ErrInvalidMovement = errors.New("invalid stock movement type")
+	ErrStockOnHand     = errors.New("stock record still holds stock; adjust it to zero first")
+)
+
+// Movement says why stock changed and who changed it. It is copied onto the
+// ledger entry; the inventory methods fill in Type where it is implied.
+type Movement struct {
+	Type          string
+	ReferenceType string
+	ReferenceID   *uuid.UUID
+	UserID        *uuid.UUID
+	Note          string
+}
+
+// InventoryService owns every change to stock levels. With a Scope it only
+// touches stock in the scope's warehouses.
+type InventoryService struct {
+	DB    *gorm.DB
+	Scope *WarehouseScope
+}
+
+func NewInventoryService(db *gorm.DB) *InventoryService {
+	return &InventoryService{DB: db}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx, which
+// attributes its changes in the audit log.
+func (s *InventoryService) WithContext(ctx context.Context) *InventoryService {
+	return &InventoryService{DB: s.DB.WithContext(ctx), Scope: s.Scope}
+}
+
+// WithTx returns a copy of the service that runs inside tx.
+func (s *InventoryService) WithTx(tx *gorm.DB) *InventoryService {
+	return &InventoryService{DB: tx, Scope: s.Scope}
+}
+
+// WithScope returns a copy of the service limited to scope.
+func (s *InventoryService) WithScope(scope *WarehouseScope) *InventoryService {
+	return &InventoryService{DB: s.DB, Scope: scope}
+}
+
+// Get returns a stock record by ID. Records outside the scope are not found.
+func (s *InventoryService) Get(id uuid.UUID) (*models.Inventory, error) {
+	var inventory models.Inventory
+	err := s.DB.Scopes(s.Scope.Filter("warehouse_id")).First(&inventory, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// Find returns the stock record for an item in a warehouse.
+func (s *InventoryService) Find(itemID, warehouseID uuid.UUID) (*models.Inventory, error) {
+	var inventory models.Inventory
+	err := s.DB.Where("item_id = ? AND warehouse_id = ?", itemID, warehouseID).First(&inventory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// Receive adds stock to a warehouse, creating the record on first receipt.
+// The movement is a receipt unless m says it is a return.
+func (s *InventoryService) Receive(itemID, warehouseID uuid.UUID, quantity int, m Movement) (*models.Inventory, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if err := s.Scope.Check(warehouseID); err != nil {
+		return nil, err
+	}
+	if m.Type == "" {
+		m.Type = MovementReceipt
+	}
+
+	var inventory *models.Inventory
+	err := retryOnConflict(func() error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if inventory, err = s.WithTx(tx).restock(itemID, warehouseID); err != nil {
+				return err
+			}
+			return s.WithTx(tx).move(inventory, quantity, m)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// restock returns the stock record to receive an item into: a new one on
+// first receipt, or the existing one, restored if it was deleted.
+func (s *InventoryService) restock(itemID, warehouseID uuid.UUID) (*models.Inventory, error) {
+	var inventory models.Inventory
+	err := s.DB.Unscoped().Where("item_id = ? AND warehouse_id = ?", itemID, warehouseID).First(&inventory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Inventory{ItemID: itemID, WarehouseID: warehouseID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if inventory.DeletedAt.Valid {
+		inventory.DeletedAt = gorm.DeletedAt{}
+		if err := updateVersioned(s.DB.Unscoped(), &inventory); err != nil {
+			return nil, err
+		}
+	}
+	return &inventory, nil
+}
+
+// Issue removes stock from a warehouse, failing if there is not enough.
+// The movement is a sale unless m says otherwise.
+func (s *InventoryService) Issue(itemID, warehouseID uuid.UUID, quantity int, m Movement) (*models.Inventory, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if err := s.Scope.Check(warehouseID); err != nil {
+		return nil, err
+	}
+	if m.Type == "" {
+		m.Type = MovementSale
+	}
+
+	var inventory *models.Inventory
+	err := retryOnConflict(func() error {
+		var err error
+		inventory, err = s.Find(itemID, warehouseID)
+		if errors.Is(err, ErrNotFound) {
+			return ErrInsufficientStock
+		}
+		if err != nil {
+			return err
+		}
+
+		// Reserved stock is only issued once its reservation is fulfilled
+		if inventory.Available < quantity {
+			return ErrInsufficientStock
+		}
+		return s.move(inventory, -quantity, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// Transfer moves stock between warehouses in a single transaction. Only
+// the source has to be in scope, so staff can ship to other warehouses.
+// Both legs share a reference ID, so they can be matched in the ledger.
+func (s *InventoryService) Transfer(itemID, fromWarehouseID, toWarehouseID uuid.UUID, quantity int, m Movement) error {
+	transferID := uuid.New()
+	m.ReferenceType, m.ReferenceID = ReferenceTransfer, &transferID
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		inv := s.WithTx(tx)
+		m.Type = MovementTransferOut
+		if _, err := inv.Issue(itemID, fromWarehouseID, quantity, m); err != nil {
+			return err
+		}
+		m.Type = MovementTransferIn
+		_, err := inv.WithScope(nil).Receive(itemID, toWarehouseID, quantity, m)
+		return err
+	})
+}
+
+// Delete removes an empty stock record. Stock still on hand has to be
+// written off with a stock adjustment first, which records a reason and may
+// need approval.
+func (s *InventoryService) Delete(id uuid.UUID) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		inventory, err := s.WithTx(tx).Get(id)
+		if err != nil {
+			return err
+		}
+		if inventory.Reserved > 0 {
+			return ErrStockReserved
+		}
+		if inventory.Quantity != 0 {
+			return ErrStockOnHand
+		}
+
+		result := tx.Where("version = ?", inventory.Version).Delete(inventory)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return ErrConflict
+		}
+		return result.Error
+	})
+}
+
+// move applies delta to a stock record and appends the ledger entry, in one
+// transaction. It is the only place quantities change. It fails with
+// ErrConflict if the record changed since it was read.
+func (s *InventoryService) move(inventory *models.Inventory, delta int, m Movement) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		inventory.Quantity += delta
+		if err := s.WithTx(tx).save(inventory); err != nil {
+			inventory.Quantity -= delta
+			return err
+		}
+
+		return tx.Create(&models.StockMovement{
+			ItemID:        inventory.ItemID,
+			WarehouseID:   inventory.WarehouseID,
+			Type:          m.Type,
+			Quantity:      delta,
+			Balance:       inventory.Quantity,
+			ReferenceType: m.ReferenceType,
+			ReferenceID:   m.ReferenceID,
+			UserID:        m.UserID,
+			Note:          m.Note,
+		}).Error
+	})
+}
+
+// save writes a stock record under its optimistic lock, creating it if it
+// is new. Creating a record that another request has just created is a
+// conflict too.
+func (s *InventoryService) save(inventory *models.Inventory) error {
+	if inventory.ID != uuid.Nil {
+		return updateVersioned(s.DB, inventory)
+	}
+
+	err := s.DB.Create(inventory).Error
+	if err != nil && isDuplicate(s.DB, err) {
+		return ErrConflict
+	}
+	return err
+}
+
+// LedgerDiscrepancy is a stock record whose quantity differs from the
+// balance rebuilt from its movements.
+type LedgerDiscrepancy struct {
+	ItemID        uuid.UUID `json:"item_id"`
+	WarehouseID   uuid.UUID `json:"warehouse_id"`
+	Quantity      int       `json:"quantity"`
+	LedgerBalance int       `json:"ledger_balance"`
+}
+
+// CheckLedger rebuilds every balance in scope from the ledger and returns
+// the stock records that disagree with it.
+func (s *InventoryService) CheckLedger() ([]LedgerDiscrepancy, error) {
+	type stockKey struct{ ItemID, WarehouseID uuid.UUID }
+	balances := make(map[stockKey]*LedgerDiscrepancy)
+	entry := func(itemID, warehouseID uuid.UUID) *LedgerDiscrepancy {
+		key := stockKey{itemID, warehouseID}
+		if balances[key] == nil {
+			balances[key] = &LedgerDiscrepancy{ItemID: itemID, WarehouseID: warehouseID}
+		}
+		return balances[key]
+	}
+
+	var sums []struct {
+		ItemID      uuid.UUID
+		WarehouseID uuid.UUID
+		Total       int
+	}
+	err := s.DB.Model(&models.StockMovement{}).Scopes(s.Scope.Filter("warehouse_id")).
+		Select("item_id, warehouse_id, SUM(quantity) AS total").
+		Group("item_id, warehouse_id").
+		Scan(&sums).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, sum := range sums {
+		entry(sum.ItemID, sum.WarehouseID).LedgerBalance = sum.Total
+	}
+
+	var stock []models.Inventory
+	if err := s.DB.Scopes(s.Scope.Filter("warehouse_id")).Find(&stock).Error; err != nil {
+		return nil, err
+	}
+	for _, inv := range stock {
+		entry(inv.ItemID, inv.WarehouseID).Quantity += inv.Quantity
+	}
+
+	discrepancies := []LedgerDiscrepancy{}
+	for _, balance := range balances {
+		if balance.Quantity != balance.LedgerBalance {
+			discrepancies = append(discrepancies, *balance)
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		a, b := discrepancies[i], discrepancies[j]
+		if a.ItemID != b.ItemID {
+			return a.ItemID.String() < b.ItemID.String()
+		}
+		return a.WarehouseID.String() < b.WarehouseID.String()
+	})
+	return discrepancies, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestDeleteRefusesStockOnHand(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 5)
+
+	if err := inventory.Delete(stock.ID); !errors.Is(err, ErrStockOnHand) {
+		t.Fatalf("deleting a record holding stock: err = %v, want ErrStockOnHand", err)
+	}
+
+	if _, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 5, Movement{Type: MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventory.Delete(stock.ID); err != nil {
+		t.Fatalf("deleting an empty record: %v", err)
+	}
+}
+
+// concurrently runs fn n times at once and returns the errors.
+func concurrently(n int, fn func() error) []error {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = fn()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// interleave makes every read on db pause, so that concurrent writers
+// read the same version before any of them writes.
+func interleave(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	err := db.Callback().Query().After("gorm:query").Register("test:interleave", func(*gorm.DB) {
+		time.Sleep(time.Millisecond)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkLedger fails the test unless every balance matches its movements.
+func checkLedger(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	discrepancies, err := NewInventoryService(db).CheckLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) > 0 {
+		t.Errorf("stock disagrees with the ledger: %+v", discrepancies)
+	}
+}
+
+func TestConcurrentIssuesNeverOversell(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 30)
+	interleave(t, db)
+
+	sold := 0
+	for _, err := range concurrently(100, func() error {
+		_, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 1, Movement{})
+		return err
+	}) {
+		switch {
+		case err == nil:
+			sold++
+		case !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, ErrConflict):
+			t.Fatal(err)
+		}
+	}
+
+	after, err := inventory.Get(stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Quantity < 0 || after.Quantity != 30-sold {
+		t.Errorf("sold %d of 30 but %d are left", sold, after.Quantity)
+	}
+	checkLedger(t, db)
+}
+
+func TestConcurrentTransfersKeepStock(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 10)
+	interleave(t, db)
+	to := uuid.New()
+
+	moved := 0
+	for _, err := range concurrently(20, func() error {
+		return inventory.Transfer(stock.ItemID, stock.WarehouseID, to, 1, Movement{})
+	}) {
+		switch {
+		case err == nil:
+			moved++
+		case !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, ErrConflict):
+			t.Fatal(err)
+		}
+	}
+
+	source, err := inventory.Get(stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := inventory.Find(stock.ItemID, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Quantity != 10-moved || target.Quantity != moved {
+		t.Errorf("moved %d: source holds %d, target %d", moved, source.Quantity, target.Quantity)
+	}
+	checkLedger(t, db)
+}
+
+func TestStaleWriteConflicts(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 5)
+
+	stale, err := inventory.Get(stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 2, Movement{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventory.move(stale, -5, Movement{Type: MovementSale}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("writing a stale record: err = %v, want ErrConflict", err)
+	}
+	if stale.Quantity != 5 {
+		t.Errorf("failed write left quantity %d, want it unchanged", stale.Quantity)
+	}
+	checkLedger(t, db)
+}
+
+func TestCheckLedgerFindsDrift(t *testing.T) {
+	db := newTestDB(t)
+	stock := receiveStock(t, db, 5)
+	receiveStock(t, db, 7)
+	if err := db.Model(stock).Update("quantity", 9).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	discrepancies, err := NewInventoryService(db).CheckLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LedgerDiscrepancy{{ItemID: stock.ItemID, WarehouseID: stock.WarehouseID, Quantity: 9, LedgerBalance: 5}}
+	if !slices.Equal(discrepancies, want) {
+		t.Errorf("discrepancies = %+v, want %+v", discrepancies, want)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ItemService manages the item catalogue.
+type ItemService struct {
+	DB *gorm.DB
+}
+
+func NewItemService(db *gorm.DB) *ItemService {
+	return &ItemService{DB: db}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx.
+func (s *ItemService) WithContext(ctx context.Context) *ItemService {
+	return &ItemService{DB: s.DB.WithContext(ctx)}
+}
+
+func (s *ItemService) Create(item *models.Item) error {
+	return s.DB.Create(item).Error
+}
+
+func (s *ItemService) Get(id uuid.UUID) (*models.Item, error) {
+	var item models.Item
+	if err := s.DB.First(&item, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Update copies the editable fields of input onto the stored item. It fails
+// with ErrConflict if input has a version and the item is no longer at it,
+// or if the item changes meanwhile.
+func (s *ItemService) Update(id uuid.UUID, input models.Item) (*models.Item, error) {
+	item, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(item, input.Version); err != nil {
+		return nil, err
+	}
+
+	item.Name = input.Name
+	item.Description = input.Description
+	item.Price = input.Price
+	// Quantity is managed via Inventory
+
+	if err := updateVersioned(s.DB, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *ItemService) Delete(id uuid.UUID) error {
+	item, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.DB.Delete(item).Error
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ThrottledError is returned while a username or address must wait before
+// trying to log in again.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *ThrottledError) Error() string {
+	if e.Locked {
+		return "account temporarily locked"
+	}
+	return "too many attempts"
+}
+
+// LoginGuard throttles failed logins. Usernames back off exponentially and
+// lock after MaxFailures; client IPs lock after the higher MaxFailuresPerIP
+// so one address cannot spray passwords across many accounts.
+type LoginGuard struct {
+	DB     *gorm.DB
+	Config config.LoginSecurity
+	Events *SecurityEventService
+}
+
+func NewLoginGuard(db *gorm.DB, cfg config.LoginSecurity, events *SecurityEventService) *LoginGuard {
+	return &LoginGuard{DB: db, Config: cfg, Events: events}
+}
+
+func userKey(username string) string { return "user:" + username }
+func ipKey(ip string) string         { return "ip:" + ip }
+func resetKey(email string) string   { return "reset:" + NormalizeEmail(email) }
+
+// Check returns a *ThrottledError if a login for username from ip must not
+// be attempted yet.
+func (g *LoginGuard) Check(username, ip string) error {
+	if throttled := g.check(userKey(username), ip); throttled != nil {
+		if !throttled.Locked {
+			g.Events.Record(EventLoginThrottled, nil, username, ip, fmt.Sprintf("retry in %s", throttled.RetryAfter.Round(time.Second)))
+		}
+		return throttled
+	}
+	return nil
+}
+
+// ResetRequested counts a password reset request against the email and the
+// address, whether or not the email is registered, and returns a
+// *ThrottledError if the reset must not be sent. Requests back off and lock
+// like failed logins, so the endpoint cannot flood an inbox.
+func (g *LoginGuard) ResetRequested(email, ip string) error {
+	if throttled := g.check(resetKey(email), ip); throttled != nil {
+		if !throttled.Locked {
+			g.Events.Record(EventResetThrottled, nil, email, ip, fmt.Sprintf("retry in %s", throttled.RetryAfter.Round(time.Second)))
+		}
+		return throttled
+	}
+	if _, err := g.fail(resetKey(email), g.Config.MaxFailures); err != nil {
+		return err
+	}
+	_, err := g.fail(ipKey(ip), g.Config.MaxFailuresPerIP)
+	return err
+}
+
+// check returns how long to wait before another attempt against key from
+// ip, or nil. Either may be locked; key also backs off between failures.
+func (g *LoginGuard) check(key, ip string) *ThrottledError {
+	now := time.Now()
+	for _, target := range []string{ipKey(ip), key} {
+		throttle, err := g.find(target)
+		if err != nil || throttle == nil {
+			continue
+		}
+
+		if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+			return &ThrottledError{RetryAfter: throttle.LockedUntil.Sub(now), Locked: true}
+		}
+
+		if target == key && g.active(throttle, now) {
+			wait := throttle.LastFailureAt.Add(g.backoff(throttle.Failures)).Sub(now)
+			if wait > 0 {
+				return &ThrottledError{RetryAfter: wait}
+			}
+		}
+	}
+	return nil
+}
+
+// Failure counts a failed login against both the username and the address.
+// Callers should not answer the attempt if it could not be counted.
+func (g *LoginGuard) Failure(username, ip string, userID *uuid.UUID) error {
+	g.Events.Record(EventLoginFailed, userID, username, ip, "")
+
+	locked, err := g.fail(userKey(username), g.Config.MaxFailures)
+	if err != nil {
+		return err
+	}
+	if locked {
+		g.Events.Record(EventAccountLocked, userID, username, ip, fmt.Sprintf("locked for %s", g.Config.LockoutDuration))
+	}
+	if locked, err = g.fail(ipKey(ip), g.Config.MaxFailuresPerIP); err != nil {
+		return err
+	}
+	if locked {
+		g.Events.Record(EventIPLocked, nil, username, ip, fmt.Sprintf("locked for %s", g.Config.LockoutDuration))
+	}
+	return nil
+}
+
+// Success clears the username's failures. The address keeps its count, so
+// a valid login cannot be used to reset a spraying attack.
+func (g *LoginGuard) Success(username, ip string, userID uuid.UUID) {
+	g.Reset(username)
+	g.Events.Record(EventLoginSucceeded, &userID, username, ip, "")
+}
+
+// Reset clears the username's failures without recording a login, for a
+// signed-in user who has just proved a credential again.
+func (g *LoginGuard) Reset(username string) {
+	g.DB.Unscoped().Where("target = ?", userKey(username)).Delete(&models.LoginThrottle{})
+}
+
+// Unlock lifts a username lockout early.
+func (g *LoginGuard) Unlock(username string, actorID *uuid.UUID) error {
+	if err := g.DB.Unscoped().Where("target = ?", userKey(username)).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return err
+	}
+
+	details := ""
+	if actorID != nil {
+		details = "unlocked by " + actorID.String()
+	}
+	g.Events.Record(EventAccountUnlocked, nil, username, "", details)
+	return nil
+}
+
+// fail records a failure for key and reports whether it caused a lockout.
+// Each step is a single conditional UPDATE, so concurrent failures are all
+// counted and only one of them locks.
+func (g *LoginGuard) fail(key string, max int) (bool, error) {
+	now := time.Now()
+	if err := g.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LoginThrottle{Target: key, LastFailureAt: now}).Error; err != nil {
+		return false, err
+	}
+	// Failures outside the window no longer count.
+	if err := g.DB.Model(&models.LoginThrottle{}).
+		Where("target = ? AND last_failure_at <= ?", key, now.Add(-g.Config.FailureWindow)).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": nil}).Error; err != nil {
+		return false, err
+	}
+	if err := g.DB.Model(&models.LoginThrottle{}).
+		Where("target = ?", key).
+		Updates(map[string]interface{}{"failures": gorm.Expr("failures + 1"), "last_failure_at": now}).Error; err != nil {
+		return false, err
+	}
+
+	result := g.DB.Model(&models.LoginThrottle{}).
+		Where("target = ? AND failures >= ? AND (locked_until IS NULL OR locked_until < ?)", key, max, now).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": now.Add(g.Config.LockoutDuration)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (g *LoginGuard) find(key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := g.DB.Where("target = ?", key).First(&throttle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// active reports whether the throttle's failures still count.
+func (g *LoginGuard) active(throttle *models.LoginThrottle, now time.Time) bool {
+	return now.Sub(throttle.LastFailureAt) < g.Config.FailureWindow
+}
+
+// backoff is the wait after n consecutive failures: base, 2*base, 4*base...
+func (g *LoginGuard) backoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	wait := g.Config.BackoffBase
+	for i := 1; i < failures && wait < g.Config.BackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > g.Config.BackoffMax {
+		wait = g.Config.BackoffMax
+	}
+	return wait
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+)
+
+func TestFailureCountsConcurrentFailures(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.Default().Login
+	cfg.MaxFailures, cfg.MaxFailuresPerIP = 1000, 1000
+	guard := NewLoginGuard(db, cfg, NewSecurityEventService(db))
+
+	const attempts = 100
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- guard.Failure("alice", "192.0.2.1", nil)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, key := range []string{userKey("alice"), ipKey("192.0.2.1")} {
+		var throttle models.LoginThrottle
+		if err := db.First(&throttle, "target = ?", key).Error; err != nil {
+			t.Fatal(err)
+		}
+		if throttle.Failures != attempts {
+			t.Errorf("%s: failures = %d, want %d", key, throttle.Failures, attempts)
+		}
+	}
+}
+
+func TestFailureLocksAfterMaxFailures(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.Default().Login
+	guard := NewLoginGuard(db, cfg, NewSecurityEventService(db))
+
+	for i := 0; i < cfg.MaxFailures; i++ {
+		if err := guard.Failure("bob", "192.0.2.2", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var throttled *ThrottledError
+	if err := guard.Check("bob", "192.0.2.3"); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("after %d failures: err = %v, want a lockout", cfg.MaxFailures, err)
+	}
+	if err := guard.Unlock("bob", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Check("bob", "192.0.2.3"); err != nil {
+		t.Errorf("after unlocking: err = %v", err)
+	}
+}
+
+func TestResetRequestedThrottlesRepeatRequests(t *testing.T) {
+	db := newTestDB(t)
+	guard := NewLoginGuard(db, config.Default().Login, NewSecurityEventService(db))
+
+	if err := guard.ResetRequested("Carol@example.com", "192.0.2.4"); err != nil {
+		t.Fatal(err)
+	}
+	var throttled *ThrottledError
+	if err := guard.ResetRequested("carol@example.com", "192.0.2.5"); !errors.As(err, &throttled) {
+		t.Fatalf("second request straight away: err = %v, want a *ThrottledError", err)
+	}
+	if err := guard.Check("carol@example.com", "192.0.2.5"); err != nil {
+		t.Errorf("reset requests throttled logins: %v", err)
+	}
+}
+
+func TestResetClearsUsernameFailures(t *testing.T) {
+	db := newTestDB(t)
+	guard := NewLoginGuard(db, config.Default().Login, NewSecurityEventService(db))
+
+	if err := guard.Failure("dave", "192.0.2.5", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Check("dave", "192.0.2.6"); err == nil {
+		t.Fatal("no backoff after a failure")
+	}
+	guard.Reset("dave")
+	if err := guard.Check("dave", "192.0.2.6"); err != nil {
+		t.Errorf("after a reset: err = %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMFACode    = errors.New("invalid MFA code")
+	ErrMFANotEnrolled    = errors.New("MFA enrolment has not been started")
+	ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+	ErrMFANotEnabled     = errors.New("MFA is not enabled")
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6 // see totp
+	// totpSkew is how many steps either side of now are accepted, to allow
+	// for clock drift between server and phone.
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+// MFAService manages TOTP enrolment (RFC 6238) and the login challenge that
+// follows a correct password.
+type MFAService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Tokens *TokenService
+	Events *SecurityEventService
+}
+
+func NewMFAService(db *gorm.DB, cfg *config.Config, tokens *TokenService, events *SecurityEventService) *MFAService {
+	return &MFAService{DB: db, Config: cfg, Tokens: tokens, Events: events}
+}
+
+// Enroll generates a new secret for a user without MFA and returns it with
+// its otpauth:// provisioning URI, ready to be shown as a QR code. MFA is
+// not enforced until Activate confirms a code.
+func (s *MFAService) Enroll(userID uuid.UUID) (string, string, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.MFAEnabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+
+	if err := s.DB.Model(&user).Updates(map[string]interface{}{"mfa_secret": secret, "mfa_last_step": 0}).Error; err != nil {
+		return "", "", err
+	}
+	return secret, s.provisioningURI(user.Username, secret), nil
+}
+
+// Activate turns MFA on once the user proves their authenticator works, and
+// returns a fresh set of recovery codes. They are only shown this once.
+func (s *MFAService) Activate(userID uuid.UUID, code, ip string) ([]string, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	var codes []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verifyTOTP(tx, &user, code); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("mfa_enabled", true).Error; err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Events.Record(EventMFAEnabled, &user.ID, user.Username, ip, "")
+	return codes, nil
+}
+
+// Disable turns MFA off. code may be a TOTP or a recovery code.
+func (s *MFAService) Disable(userID uuid.UUID, code, ip string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(tx, &user, code); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"mfa_enabled":   false,
+			"mfa_secret":    "",
+			"mfa_last_step": 0,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Events.Record(EventMFADisabled, &user.ID, user.Username, ip, "")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user.
+func (s *MFAService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	var codes []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verifyTOTP(tx, &user, code); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// Challenge issues the short-lived token a user with MFA exchanges, along
+// with a code, for a session.
+func (s *MFAService) Challenge(userID uuid.UUID) (string, error) {
+	return s.Tokens.Sign(jwt.MapClaims{
+		"typ":     "mfa",
+		"user_id": userID.String(),
+	}, s.Config.MFA.ChallengeTTL)
+}
+
+// ParseChallenge returns the user a challenge token was issued to.
+func (s *MFAService) ParseChallenge(token string) (uuid.UUID, error) {
+	claims, err := s.Tokens.Parse(token)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	if typ, _ := claims["typ"].(string); typ != "mfa" {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return uuidClaim(claims, "user_id")
+}
+
+// Verify checks a TOTP or recovery code for a user with MFA enabled. Each
+// code is accepted at most once.
+func (s *MFAService) Verify(user *models.User, code string) error {
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.verify(tx, user, code)
+	})
+}
+
+func (s *MFAService) verify(tx *gorm.DB, user *models.User, code string) error {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) == totpDigits {
+		return s.verifyTOTP(tx, user, code)
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) verifyTOTP(tx *gorm.DB, user *models.User, code string) error {
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(user.MFASecret)
+	if err != nil {
+		return ErrMFANotEnrolled
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if step <= user.MFALastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totp(secret, step)), []byte(code)) != 1 {
+			continue
+		}
+
+		// Record the step so the same code cannot be used again, even by a
+		// concurrent request.
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND mfa_last_step < ?", user.ID, step).
+			Update("mfa_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidMFACode
+		}
+		user.MFALastStep = step
+		return nil
+	}
+	return ErrInvalidMFACode
+}
+
+func (s *MFAService) replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *MFAService) provisioningURI(username, secret string) string {
+	issuer := s.Config.MFA.Issuer
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func (s *MFAService) user(userID uuid.UUID) (models.User, error) {
+	var user models.User
+	if err := s.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, ErrNotFound
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// hashRecoveryCode ignores case and the dash, which only aids reading.
+func hashRecoveryCode(code string) string {
+	return hashToken(strings.ToLower(strings.ReplaceAll(code, "-", "")))
+}
+
+// totp computes the code for a time step (RFC 4226 dynamic truncation).
+func totp(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"go-rest/internal/config"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier delivers a message to a user, for example by email.
+type Notifier interface {
+	Notify(to, subject, body string) error
+}
+
+// NewNotifier returns the notifier selected by cfg.
+func NewNotifier(cfg config.NotifierConfig) (Notifier, error) {
+	switch cfg.Driver {
+	case "log":
+		return LogNotifier{}, nil
+	case "file":
+		return &FileNotifier{Path: cfg.File}, nil
+	default:
+		return nil, fmt.Errorf("unsupported notifier %q", cfg.Driver)
+	}
+}
+
+// LogNotifier records in the server log that a message was sent, but not
+// the message, which may hold a secret such as a reset token. It delivers
+// nothing; use FileNotifier to read messages during development.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(to, subject, body string) error {
+	log.Printf("Notification to %s: %s (not delivered by the log notifier)", to, subject)
+	return nil
+}
+
+// FileNotifier appends messages to a file.
+type FileNotifier struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (n *FileNotifier) Notify(to, subject, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), to, subject, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogNotifierKeepsMessagesOutOfTheLog(t *testing.T) {
+	var out bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&out)
+
+	if err := (LogNotifier{}).Notify("alice@example.com", "Reset your password", "secret-reset-token"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "secret-reset-token") {
+		t.Errorf("log holds the message body: %q", out.String())
+	}
+}
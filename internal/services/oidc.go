@@ -0,0 +1,533 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCDisabled = errors.New("single sign-on is not configured")
+	ErrInvalidState = errors.New("invalid or expired login state")
+)
+
+// oidcStateTTL bounds how long a user may spend at the identity provider.
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCIdentity is what a verified ID token says about the user.
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+	// MFA is set when the provider reports a second factor in amr.
+	MFA bool
+}
+
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCService signs users in through an OpenID Connect provider with the
+// authorization code flow and PKCE.
+type OIDCService struct {
+	DB     *gorm.DB
+	Config config.OIDCConfig
+	Users  *UserService
+	Client *http.Client
+
+	mu       sync.Mutex
+	provider *oidcProvider
+	keys     map[string]interface{}
+}
+
+func NewOIDCService(db *gorm.DB, cfg config.OIDCConfig, users *UserService) *OIDCService {
+	return &OIDCService{DB: db, Config: cfg, Users: users, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Enabled reports whether single sign-on is configured.
+func (s *OIDCService) Enabled() bool {
+	return s.Config.IssuerURL != ""
+}
+
+// AuthURL starts a login and returns the provider URL to send the user to.
+func (s *OIDCService) AuthURL(ctx context.Context) (string, error) {
+	if !s.Enabled() {
+		return "", ErrOIDCDisabled
+	}
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := s.DB.Unscoped().Where("expires_at < ?", now).Delete(&models.OIDCState{}).Error; err != nil {
+		return "", err
+	}
+	if err := s.DB.Create(&models.OIDCState{
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(oidcStateTTL),
+	}).Error; err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", s.Config.ClientID)
+	query.Set("redirect_uri", s.Config.RedirectURL)
+	query.Set("scope", strings.Join(s.Config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return provider.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Callback completes a login: it exchanges the code, verifies the ID token
+// and returns the local user, provisioned or linked as needed.
+func (s *OIDCService) Callback(ctx context.Context, code, state string) (*models.User, *OIDCIdentity, error) {
+	if !s.Enabled() {
+		return nil, nil, ErrOIDCDisabled
+	}
+
+	// Each state can complete one login.
+	var pending models.OIDCState
+	if err := s.DB.Where("state_hash = ?", hashToken(state)).First(&pending).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidState
+		}
+		return nil, nil, err
+	}
+	result := s.DB.Unscoped().Delete(&pending)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(pending.ExpiresAt) {
+		return nil, nil, ErrInvalidState
+	}
+
+	rawIDToken, err := s.exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := s.verify(ctx, rawIDToken, pending.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.provision(identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, identity, nil
+}
+
+func (s *OIDCService) discover(ctx context.Context) (*oidcProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	var provider oidcProvider
+	wellKnown := strings.TrimSuffix(s.Config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := s.getJSON(ctx, wellKnown, &provider); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if provider.Issuer != s.Config.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", provider.Issuer, s.Config.IssuerURL)
+	}
+
+	s.provider = &provider
+	return s.provider, nil
+}
+
+func (s *OIDCService) exchange(ctx context.Context, code, verifier string) (string, error) {
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.Config.RedirectURL)
+	form.Set("client_id", s.Config.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.Config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.Config.ClientID), url.QueryEscape(s.Config.ClientSecret))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return "", fmt.Errorf("oidc token exchange: %s %s", body.Error, body.ErrorDescription)
+	}
+	return body.IDToken, nil
+}
+
+func (s *OIDCService) verify(ctx context.Context, rawIDToken, nonce string) (*OIDCIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(s.Config.IssuerURL),
+		jwt.WithAudience(s.Config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, ErrInvalidToken
+	}
+
+	identity := &OIDCIdentity{Issuer: s.Config.IssuerURL}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Username, _ = claims[s.Config.UsernameClaim].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.Groups = stringList(claims[s.Config.GroupsClaim])
+	for _, method := range stringList(claims["amr"]) {
+		if method == "mfa" || method == "otp" || method == "hwk" {
+			identity.MFA = true
+		}
+	}
+	if identity.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return identity, nil
+}
+
+// key returns the provider's signing key with the given ID, refetching the
+// key set once when the provider has rotated keys.
+func (s *OIDCService) key(ctx context.Context, kid string) (interface{}, error) {
+	s.mu.Lock()
+	key, ok := s.keys[kid]
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set JWKS
+	if err := s.getJSON(ctx, provider.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if public, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = public
+		}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// provision finds the user linked to identity, links an existing user by
+// email, or creates one, then applies the roles mapped from the identity's
+// groups. Linking needs LinkByEmail and an email that both the provider and
+// this server have verified: anyone can register an unverified email, and
+// linking it would hand them the SSO user's account.
+func (s *OIDCService) provision(identity *OIDCIdentity) (*models.User, error) {
+	var user models.User
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("oidc_issuer = ? AND oidc_subject = ?", identity.Issuer, identity.Subject).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		found := err == nil
+
+		email := NormalizeEmail(identity.Email)
+		if !found && s.Config.LinkByEmail && identity.EmailVerified && email != "" {
+			err := tx.Where("email = ? AND email_verified_at IS NOT NULL AND oidc_subject IS NULL", email).First(&user).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				found = true
+				if err := tx.Model(&user).Updates(map[string]interface{}{
+					"oidc_issuer":  identity.Issuer,
+					"oidc_subject": identity.Subject,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		roles, err := s.mapRoles(tx, identity.Groups)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			username, err := s.username(tx, identity)
+			if err != nil {
+				return err
+			}
+			user = models.User{
+				Username:    username,
+				OIDCIssuer:  &identity.Issuer,
+				OIDCSubject: &identity.Subject,
+			}
+			if email != "" && identity.EmailVerified {
+				var taken int64
+				if err := tx.Model(&models.User{}).Unscoped().Where("email = ?", email).Count(&taken).Error; err != nil {
+					return err
+				}
+				if taken == 0 {
+					now := time.Now()
+					user.Email = &email
+					user.EmailVerifiedAt = &now
+				}
+			}
+			if len(roles) == 0 {
+				name := s.Config.DefaultRole
+				if name == "" {
+					name = s.Users.Config.Registration.DefaultRole
+				}
+				role, err := s.Users.EnsureRole(tx, name, false)
+				if err != nil {
+					return err
+				}
+				roles = []uuid.UUID{role.ID}
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			return addRoles(tx, user.ID, roles...)
+		}
+
+		// The identity provider is authoritative for mapped roles, but a
+		// changed group cannot demote the last admin.
+		if len(roles) > 0 {
+			return guardLastAdmin(tx, func() error {
+				return setRoles(tx, user.ID, roles)
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Users.Cache.InvalidateUser(user.ID)
+	return &user, nil
+}
+
+// mapRoles returns the IDs of the roles that RoleMapping maps groups to. A
+// group is never taken for a role of the same name: whoever controls group
+// names at the provider would otherwise control every role here.
+func (s *OIDCService) mapRoles(tx *gorm.DB, groups []string) ([]uuid.UUID, error) {
+	var roles []uuid.UUID
+	for _, group := range groups {
+		name, ok := s.Config.RoleMapping[group]
+		if !ok {
+			continue
+		}
+		role, err := s.findRole(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil {
+			roles = append(roles, role.ID)
+		}
+	}
+	return roles, nil
+}
+
+func (s *OIDCService) findRole(tx *gorm.DB, name string) (*models.Role, error) {
+	var role models.Role
+	if err := tx.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// username picks a free username for a new user, preferring the username
+// claim, then the email's local part, then the subject.
+func (s *OIDCService) username(tx *gorm.DB, identity *OIDCIdentity) (string, error) {
+	base := identity.Username
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if base == "" {
+		base = identity.Subject
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var taken int64
+		if err := tx.Model(&models.User{}).Unscoped().Where("username = ?", candidate).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return candidate, nil
+		}
+		candidate = base + "-" + uuid.NewString()[:6]
+	}
+	return "", errors.New("could not find a free username for " + base)
+}
+
+func (s *OIDCService) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// PublicKey decodes an RSA, EC or Ed25519 JWK.
+func (k JWK) PublicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// stringList reads a claim that may be a single string or a list.
+func stringList(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				list = append(list, str)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go-rest/internal/config"
+	"go-rest/internal/mockoidc"
+	"go-rest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newTestOIDC returns an OIDC service signing in through a mock provider.
+func newTestOIDC(t *testing.T, db *gorm.DB, configure func(*config.OIDCConfig)) *OIDCService {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewUnstartedServer(nil)
+	issuer := "http://" + server.Listener.Addr().String()
+	provider, err := mockoidc.New(issuer, "inventory", "", url.Values{"amr": {"pwd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Config.Handler = provider.Handler()
+	server.Start()
+	t.Cleanup(server.Close)
+
+	cfg := config.Default()
+	cfg.OIDC.IssuerURL = issuer
+	cfg.OIDC.ClientID = "inventory"
+	cfg.OIDC.RedirectURL = "http://app.example.com/callback"
+	if configure != nil {
+		configure(&cfg.OIDC)
+	}
+	return NewOIDCService(db, cfg.OIDC, NewUserService(db, cfg, NewPermissionCache(db, 0)))
+}
+
+// signIn completes a login as the identity the claims describe.
+func signIn(t *testing.T, s *OIDCService, claims url.Values) *models.User {
+	t.Helper()
+	user, err := trySignIn(t, s, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// trySignIn is signIn for logins that may fail in the callback.
+func trySignIn(t *testing.T, s *OIDCService, claims url.Values) (*models.User, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, err := s.AuthURL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorize, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authorize.Query()
+	for name, values := range claims {
+		query[name] = values
+	}
+	authorize.RawQuery = query.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authorize.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatalf("authorize: %s: %v", resp.Status, err)
+	}
+
+	user, _, err := s.Callback(ctx, callback.Query().Get("code"), callback.Query().Get("state"))
+	return user, err
+}
+
+func verifyEmail(t *testing.T, db *gorm.DB, user *models.User) {
+	t.Helper()
+	if err := db.Model(user).Update("email_verified_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOIDCDoesNotLinkByEmailByDefault(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	local := createUser(t, db, "alice")
+	verifyEmail(t, db, local)
+
+	user := signIn(t, newTestOIDC(t, db, nil), url.Values{"sub": {"alice-sso"}, "email": {"alice@example.com"}})
+	if user.ID == local.ID {
+		t.Fatal("linked an existing user by email with LinkByEmail off")
+	}
+	if user.Email != nil {
+		t.Errorf("new user took the email %s of an existing user", *user.Email)
+	}
+}
+
+func TestOIDCLinksOnlyVerifiedEmails(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	oidc := newTestOIDC(t, db, func(cfg *config.OIDCConfig) { cfg.LinkByEmail = true })
+
+	// Anyone can register an email they do not own.
+	squatter := createUser(t, db, "squatter")
+	user := signIn(t, oidc, url.Values{"sub": {"squatter-sso"}, "email": {"squatter@example.com"}})
+	if user.ID == squatter.ID {
+		t.Error("linked a user whose email this server never verified")
+	}
+
+	local := createUser(t, db, "bob")
+	verifyEmail(t, db, local)
+	user = signIn(t, oidc, url.Values{"sub": {"bob-unverified"}, "email": {"bob@example.com"}, "email_verified": {"false"}})
+	if user.ID == local.ID {
+		t.Error("linked a user by an email the provider has not verified")
+	}
+
+	user = signIn(t, oidc, url.Values{"sub": {"bob-sso"}, "email": {"bob@example.com"}})
+	if user.ID != local.ID {
+		t.Fatal("did not link a user whose email both sides verified")
+	}
+	if again := signIn(t, oidc, url.Values{"sub": {"bob-sso"}}); again.ID != local.ID {
+		t.Error("a linked identity signed in as another user")
+	}
+}
+
+func TestOIDCGrantsOnlyMappedRoles(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+
+	user := signIn(t, newTestOIDC(t, db, nil), url.Values{"sub": {"carol-sso"}, "groups": {"admin"}})
+	roles, err := UserRoleIDs(db, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0] != roleID(t, db, "user") {
+		t.Errorf("unmapped group admin gave roles %v, want only the default role", roles)
+	}
+
+	mapped := newTestOIDC(t, db, func(cfg *config.OIDCConfig) {
+		cfg.RoleMapping = map[string]string{"inventory-admins": "admin"}
+	})
+	user = signIn(t, mapped, url.Values{"sub": {"dave-sso"}, "groups": {"admin,inventory-admins"}})
+	roles, err = UserRoleIDs(db, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0] != roleID(t, db, "admin") {
+		t.Errorf("mapped group gave roles %v, want only admin", roles)
+	}
+}
+
+func TestOIDCKeepsTheLastAdmin(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	oidc := newTestOIDC(t, db, func(cfg *config.OIDCConfig) {
+		cfg.RoleMapping = map[string]string{"inventory-admins": "admin", "staff": "viewer"}
+	})
+
+	admin := signIn(t, oidc, url.Values{"sub": {"erin-sso"}, "groups": {"inventory-admins"}})
+	if _, err := trySignIn(t, oidc, url.Values{"sub": {"erin-sso"}, "groups": {"staff"}}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("demoting the last admin through a group change: err = %v, want ErrLastAdmin", err)
+	}
+	roles, err := UserRoleIDs(db, admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0] != roleID(t, db, "admin") {
+		t.Errorf("last admin has roles %v after the failed login, want only admin", roles)
+	}
+}
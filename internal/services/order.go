@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-rest/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderLine is a single item line on a sales or purchase order.
+type OrderLine struct {
+	ItemID    uuid.UUID
+	Quantity  int
+	UnitPrice float64
+	// ReservationID is stock held for this line. Selling it fulfils the
+	// reservation; any part of it not sold becomes available again.
+	ReservationID *uuid.UUID
+}
+
+// OrderService creates sales orders and deducts the sold stock.
+type OrderService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+}
+
+func NewOrderService(db *gorm.DB, inventory *InventoryService) *OrderService {
+	return &OrderService{DB: db, Inventory: inventory}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx.
+func (s *OrderService) WithContext(ctx context.Context) *OrderService {
+	return &OrderService{DB: s.DB.WithContext(ctx), Inventory: s.Inventory.WithContext(ctx)}
+}
+
+// WithScope returns a copy of the service that only sells from warehouses
+// in scope.
+func (s *OrderService) WithScope(scope *WarehouseScope) *OrderService {
+	return &OrderService{DB: s.DB, Inventory: s.Inventory.WithScope(scope)}
+}
+
+// Create records a completed sale, issuing stock for every line atomically.
+func (s *OrderService) Create(userID, warehouseID uuid.UUID, paymentMethod string, lines []OrderLine) (*models.Order, error) {
+	order := models.Order{
+		UserID:        userID,
+		WarehouseID:   warehouseID,
+		Status:        "Completed",
+		PaymentMethod: paymentMethod,
+		Date:          time.Now(),
+	}
+
+	for _, line := range lines {
+		order.TotalAmount += float64(line.Quantity) * line.UnitPrice
+		order.Items = append(order.Items, models.OrderItem{
+			ItemID:    line.ItemID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+	}
+
+	// The order is stored first so each sale in the ledger can point at it
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		inv := s.Inventory.WithTx(tx)
+		sale := Movement{ReferenceType: ReferenceOrder, ReferenceID: &order.ID, UserID: &userID}
+		for _, line := range lines {
+			if line.ReservationID != nil {
+				if err := inv.Fulfil(*line.ReservationID, line.ItemID, warehouseID, order.ID); err != nil {
+					return fmt.Errorf("item %s: %w", line.ItemID, err)
+				}
+			}
+			if _, err := inv.Issue(line.ItemID, warehouseID, line.Quantity, sale); err != nil {
+				return fmt.Errorf("item %s: %w", line.ItemID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
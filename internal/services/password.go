@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrWeakPassword wraps every password policy violation.
+var ErrWeakPassword = errors.New("password does not meet policy")
+
+// ValidatePassword checks password against policy, listing every rule it
+// breaks so the user can fix them in one go.
+func ValidatePassword(policy config.PasswordPolicy, password, username string) error {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	var problems []string
+	if len([]rune(password)) < policy.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if policy.RequireUpper && !upper {
+		problems = append(problems, "an uppercase letter")
+	}
+	if policy.RequireLower && !lower {
+		problems = append(problems, "a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		problems = append(problems, "a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		problems = append(problems, "a symbol")
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		problems = append(problems, "to differ from the username")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: needs %s", ErrWeakPassword, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// ErrWrongPassword is returned when the current password does not match.
+var ErrWrongPassword = errors.New("current password is incorrect")
+
+// PasswordService changes and resets user passwords.
+type PasswordService struct {
+	DB       *gorm.DB
+	Config   *config.Config
+	Sessions *SessionService
+	Notifier Notifier
+	Events   *SecurityEventService
+}
+
+func NewPasswordService(db *gorm.DB, cfg *config.Config, sessions *SessionService, notifier Notifier, events *SecurityEventService) *PasswordService {
+	return &PasswordService{DB: db, Config: cfg, Sessions: sessions, Notifier: notifier, Events: events}
+}
+
+// Change replaces the password of a user who knows the current one and
+// revokes every other session. keep is the session making the change, or
+// uuid.Nil to revoke them all.
+func (s *PasswordService) Change(userID, keep uuid.UUID, current, next, ip string) error {
+	var user models.User
+	if err := s.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(current)); err != nil {
+		return ErrWrongPassword
+	}
+	if err := s.setPassword(s.DB, &user, next); err != nil {
+		return err
+	}
+	if err := s.Sessions.RevokeAll(user.ID, keep); err != nil {
+		return err
+	}
+
+	s.Events.Record(EventPasswordChanged, &user.ID, user.Username, ip, "")
+	return nil
+}
+
+// RequestReset sends a reset link to the user registered with email. An
+// unknown email is not an error, so callers cannot probe for accounts.
+func (s *PasswordService) RequestReset(email, ip string) error {
+	email = NormalizeEmail(email)
+
+	var user models.User
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent link works.
+		if err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordReset{
+			UserID:    user.ID,
+			TokenHash: hashToken(raw),
+			ExpiresAt: now.Add(s.Config.PasswordReset.TTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Use this to reset your password within %s:\n\n%s\n\nIf you did not ask for a reset, ignore this message.",
+		s.Config.PasswordReset.TTL, s.resetLink(raw))
+	if err := s.Notifier.Notify(email, "Reset your password", body); err != nil {
+		return err
+	}
+
+	s.Events.Record(EventPasswordResetRequested, &user.ID, user.Username, ip, "")
+	return nil
+}
+
+// Reset sets a new password using a token from RequestReset. The token is
+// used up and every session of the user is revoked.
+func (s *PasswordService) Reset(raw, password, ip string) error {
+	now := time.Now()
+
+	var reset models.PasswordReset
+	if err := s.DB.Where("token_hash = ?", hashToken(raw)).First(&reset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	if reset.UsedAt != nil || now.After(reset.ExpiresAt) {
+		return ErrInvalidToken
+	}
+
+	var user models.User
+	if err := s.DB.First(&user, "id = ?", reset.UserID).Error; err != nil {
+		return ErrInvalidToken
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordReset{}).
+			Where("id = ? AND used_at IS NULL", reset.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+		if err := s.setPassword(tx, &user, password); err != nil {
+			return err
+		}
+		// The token was mailed to the user's email, so it is theirs.
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email IS NOT NULL AND email_verified_at IS NULL", user.ID).
+			Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.Sessions.RevokeAll(user.ID, uuid.Nil); err != nil {
+		return err
+	}
+
+	s.Events.Record(EventPasswordReset, &user.ID, user.Username, ip, "")
+	return nil
+}
+
+func (s *PasswordService) setPassword(tx *gorm.DB, user *models.User, password string) error {
+	if err := ValidatePassword(s.Config.Password, password, user.Username); err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return tx.Model(user).Update("password", string(hashed)).Error
+}
+
+func (s *PasswordService) resetLink(raw string) string {
+	if s.Config.PasswordReset.URL == "" {
+		return raw
+	}
+	link, err := url.Parse(s.Config.PasswordReset.URL)
+	if err != nil {
+		return raw
+	}
+	query := link.Query()
+	query.Set("token", raw)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// NormalizeEmail is how emails are stored and looked up.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
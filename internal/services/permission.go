@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidPermission = errors.New("permission must be resource:action, with optional * wildcards and dotted sub-resources")
+
+var (
+	resourcePattern = regexp.MustCompile(`^(\*|[a-z0-9_]+(\.[a-z0-9_]+)*(\.\*)?)$`)
+	actionPattern   = regexp.MustCompile(`^(\*|[a-z0-9_]+)$`)
+)
+
+// ValidatePermission checks that a granted permission is well formed.
+// Resources are dotted paths such as inventory.transfer; a grant on a
+// resource also covers everything below it, "inventory.*" covers only what
+// is below, and "*" on either side matches anything.
+func ValidatePermission(resource, action string) error {
+	if !resourcePattern.MatchString(resource) || !actionPattern.MatchString(action) {
+		return ErrInvalidPermission
+	}
+	return nil
+}
+
+// HasPermission reports whether the granted "resource:action" set allows
+// action on resource. The requested pair may itself contain wildcards, in
+// which case it is allowed only if something at least as broad is granted.
+func HasPermission(granted map[string]bool, resource, action string) bool {
+	actions := []string{action}
+	if action != "*" {
+		actions = append(actions, "*")
+	}
+
+	for _, r := range coveringResources(resource) {
+		for _, a := range actions {
+			if granted[r+":"+a] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// coveringResources lists every resource pattern whose grant covers
+// resource: itself, each ancestor and its ".*" form, and "*".
+func coveringResources(resource string) []string {
+	if resource == "*" {
+		return []string{"*"}
+	}
+
+	segments := strings.Split(resource, ".")
+	covering := []string{resource}
+	for i := 1; i < len(segments); i++ {
+		ancestor := strings.Join(segments[:i], ".")
+		covering = append(covering, ancestor, ancestor+".*")
+	}
+	return append(covering, "*")
+}
+
+// catalog holds every permission the application checks.
+var catalog = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+// RegisterPermission adds resource:action to the catalog. Routes register
+// the permissions they require as they are set up.
+func RegisterPermission(resource, action string) {
+	catalog.Lock()
+	catalog.names[resource+":"+action] = true
+	catalog.Unlock()
+}
+
+// PermissionCatalog returns every registered permission, sorted.
+func PermissionCatalog() []string {
+	catalog.Lock()
+	defer catalog.Unlock()
+
+	names := make([]string, 0, len(catalog.names))
+	for name := range catalog.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inCatalog reports whether a granted permission would allow at least one
+// registered permission. With nothing registered every permission is
+// accepted.
+func inCatalog(resource, action string) bool {
+	names := PermissionCatalog()
+	if len(names) == 0 {
+		return true
+	}
+
+	granted := map[string]bool{resource + ":" + action: true}
+	for _, name := range names {
+		r, a, _ := strings.Cut(name, ":")
+		if HasPermission(granted, r, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PermissionCache keeps each role, resolved with everything it inherits,
+// and each user's role IDs in memory so authenticating a request does not
+// have to load them every time. Entries expire after TTL; anything that
+// changes a role's permissions or parent must call Invalidate, and anything
+// that changes a user's roles InvalidateUser, so this instance sees it at
+// once, while other instances catch up when their entry expires.
+type PermissionCache struct {
+	DB  *gorm.DB
+	TTL time.Duration
+
+	mu    sync.RWMutex
+	roles map[uuid.UUID]cachedRole
+	users map[uuid.UUID]cachedUser
+	// generation counts invalidations. A load that began before one may
+	// have read what it invalidated, so it is not stored.
+	generation uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+type cachedRole struct {
+	*ResolvedRole
+	ExpiresAt time.Time
+}
+
+type cachedUser struct {
+	RoleIDs   []uuid.UUID
+	ExpiresAt time.Time
+}
+
+// PermissionCacheStats reports how well the cache is doing.
+type PermissionCacheStats struct {
+	Enabled       bool    `json:"enabled"`
+	TTL           string  `json:"ttl"`
+	Entries       int     `json:"entries"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRate       float64 `json:"hit_rate"`
+	Invalidations uint64  `json:"invalidations"`
+}
+
+func NewPermissionCache(db *gorm.DB, ttl time.Duration) *PermissionCache {
+	return &PermissionCache{
+		DB:    db,
+		TTL:   ttl,
+		roles: make(map[uuid.UUID]cachedRole),
+		users: make(map[uuid.UUID]cachedUser),
+	}
+}
+
+// Role returns a role resolved with its ancestors. The result is shared
+// between callers and must not be modified.
+func (c *PermissionCache) Role(id uuid.UUID) (*ResolvedRole, error) {
+	now := time.Now()
+	c.mu.RLock()
+	entry, ok := c.roles[id]
+	generation := c.generation
+	c.mu.RUnlock()
+	if c.TTL > 0 && ok && now.Before(entry.ExpiresAt) {
+		c.hits.Add(1)
+		return entry.ResolvedRole, nil
+	}
+	c.misses.Add(1)
+
+	resolved, err := ResolveRole(c.DB, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.TTL > 0 {
+		c.mu.Lock()
+		if c.generation == generation {
+			c.roles[id] = cachedRole{ResolvedRole: resolved, ExpiresAt: now.Add(c.TTL)}
+		}
+		c.mu.Unlock()
+	}
+	return resolved, nil
+}
+
+// UserRoleIDs returns the IDs of the roles a user holds. The result is
+// shared between callers and must not be modified.
+func (c *PermissionCache) UserRoleIDs(userID uuid.UUID) ([]uuid.UUID, error) {
+	now := time.Now()
+	c.mu.RLock()
+	entry, ok := c.users[userID]
+	generation := c.generation
+	c.mu.RUnlock()
+	if c.TTL > 0 && ok && now.Before(entry.ExpiresAt) {
+		c.hits.Add(1)
+		return entry.RoleIDs, nil
+	}
+	c.misses.Add(1)
+
+	roleIDs, err := UserRoleIDs(c.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.TTL > 0 {
+		c.mu.Lock()
+		if c.generation == generation {
+			c.users[userID] = cachedUser{RoleIDs: roleIDs, ExpiresAt: now.Add(c.TTL)}
+		}
+		c.mu.Unlock()
+	}
+	return roleIDs, nil
+}
+
+// Invalidate drops the cached entries of the given roles and of every role
+// inheriting from them.
+func (c *PermissionCache) Invalidate(roleIDs ...uuid.UUID) {
+	c.mu.Lock()
+	for id, entry := range c.roles {
+		for _, changed := range roleIDs {
+			if entry.Inherits(changed) {
+				delete(c.roles, id)
+				break
+			}
+		}
+	}
+	c.generation++
+	c.mu.Unlock()
+	c.invalidations.Add(1)
+}
+
+// InvalidateUser drops the cached role IDs of the given users.
+func (c *PermissionCache) InvalidateUser(userIDs ...uuid.UUID) {
+	c.mu.Lock()
+	for _, id := range userIDs {
+		delete(c.users, id)
+	}
+	c.generation++
+	c.mu.Unlock()
+	c.invalidations.Add(1)
+}
+
+// InvalidateAll empties the cache, for changes such as editing a
+// permission that may be granted by any role.
+func (c *PermissionCache) InvalidateAll() {
+	c.mu.Lock()
+	c.roles = make(map[uuid.UUID]cachedRole)
+	c.users = make(map[uuid.UUID]cachedUser)
+	c.generation++
+	c.mu.Unlock()
+	c.invalidations.Add(1)
+}
+
+func (c *PermissionCache) Stats() PermissionCacheStats {
+	c.mu.RLock()
+	entries := len(c.roles) + len(c.users)
+	c.mu.RUnlock()
+
+	stats := PermissionCacheStats{
+		Enabled:       c.TTL > 0,
+		TTL:           c.TTL.String(),
+		Entries:       entries,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
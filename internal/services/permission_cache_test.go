@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestPermissionCacheServesUserRoles(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	cache := NewPermissionCache(db, time.Minute)
+	users := NewUserService(db, config.Default(), cache)
+	user := createUser(t, db, "alice", "viewer")
+
+	if _, err := cache.UserRoleIDs(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Behind the cache's back, so only the cached roles are seen.
+	if err := db.Create(&models.UserRole{UserID: user.ID, RoleID: roleID(t, db, "manager")}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if roles, err := cache.UserRoleIDs(user.ID); err != nil || len(roles) != 1 {
+		t.Fatalf("roles = %v, %v; want the one cached role", roles, err)
+	}
+
+	if _, err := users.AddRole(user.ID, roleID(t, db, "admin")); err != nil {
+		t.Fatal(err)
+	}
+	if roles, err := cache.UserRoleIDs(user.ID); err != nil || len(roles) != 3 {
+		t.Errorf("after AddRole: roles = %v, %v; want 3", roles, err)
+	}
+}
+
+func TestPermissionCacheDropsLoadsThatRaceAnInvalidation(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	cache := NewPermissionCache(db, time.Minute)
+	user := createUser(t, db, "alice", "viewer")
+	viewer := roleID(t, db, "viewer")
+
+	// Invalidate while a load is reading the database, as a concurrent
+	// change would.
+	var during func()
+	err := db.Callback().Query().After("gorm:query").Register("test:invalidate", func(*gorm.DB) {
+		if invalidate := during; invalidate != nil {
+			during = nil
+			invalidate()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	during = func() { cache.InvalidateUser(user.ID) }
+	if _, err := cache.UserRoleIDs(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	during = func() { cache.Invalidate(viewer) }
+	if _, err := cache.Role(viewer); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries := cache.Stats().Entries; entries != 0 {
+		t.Errorf("cached %d entries loaded before an invalidation, want 0", entries)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		granted          string
+		resource, action string
+		want             bool
+	}{
+		{"items:read", "items", "read", true},
+		{"items:read", "items", "write", false},
+		{"items:*", "items", "write", true},
+		{"*:read", "orders", "read", true},
+		{"*:*", "anything.below", "delete", true},
+		{"inventory:write", "inventory.transfer", "write", true},
+		{"inventory.*:write", "inventory.transfer", "write", true},
+		{"inventory.*:write", "inventory", "write", false},
+		{"inventory.transfer:write", "inventory", "write", false},
+		{"inventory.transfer:write", "inventory.adjust", "write", false},
+		{"items:read", "items", "*", false},
+		{"items:*", "items", "*", true},
+		{"items:read", "*", "read", false},
+		{"warehouses:*", AllWarehousesResource, AllWarehousesAction, false},
+	}
+	for _, test := range tests {
+		granted := map[string]bool{test.granted: true}
+		if got := HasPermission(granted, test.resource, test.action); got != test.want {
+			t.Errorf("%s allows %s:%s = %v, want %v", test.granted, test.resource, test.action, got, test.want)
+		}
+	}
+}
+
+func TestValidatePermission(t *testing.T) {
+	valid := [][2]string{{"items", "read"}, {"*", "*"}, {"inventory.transfer", "write"}, {"inventory.*", "write"}}
+	for _, p := range valid {
+		if err := ValidatePermission(p[0], p[1]); err != nil {
+			t.Errorf("%s:%s rejected: %v", p[0], p[1], err)
+		}
+	}
+	invalid := [][2]string{{"", "read"}, {"items", ""}, {"Items", "read"}, {"items.", "read"}, {"*.items", "read"}, {"items", "re*d"}}
+	for _, p := range invalid {
+		if err := ValidatePermission(p[0], p[1]); err == nil {
+			t.Errorf("%s:%s accepted", p[0], p[1])
+		}
+	}
+}
+
+func TestDefaultRolesInherit(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+
+	viewer, clerk, manager := rolePermissions(t, db, "viewer"), rolePermissions(t, db, "clerk"), rolePermissions(t, db, "manager")
+	if !HasPermission(clerk, "items", "read") {
+		t.Error("clerk does not inherit the viewer's read access")
+	}
+	if !HasPermission(manager, "inventory", "write") {
+		t.Error("manager does not inherit the clerk's stock access")
+	}
+	if HasPermission(viewer, "inventory", "write") {
+		t.Error("viewer can change stock")
+	}
+	if HasPermission(clerk, AllWarehousesResource, AllWarehousesAction) {
+		t.Error("clerk works outside their assigned warehouses")
+	}
+	if HasPermission(manager, "roles", "write") || HasPermission(manager, "users", "write") {
+		t.Error("manager can administer users or roles")
+	}
+}
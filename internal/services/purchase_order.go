@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Purchase order statuses.
+const (
+	POStatusPending   = "Pending"
+	POStatusReceived  = "Received"
+	POStatusCancelled = "Cancelled"
+)
+
+// PurchaseOrderService manages purchase orders and receives their stock.
+// With a Scope it only sees orders for the scope's warehouses.
+type PurchaseOrderService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+	Scope     *WarehouseScope
+}
+
+func NewPurchaseOrderService(db *gorm.DB, inventory *InventoryService) *PurchaseOrderService {
+	return &PurchaseOrderService{DB: db, Inventory: inventory}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx.
+func (s *PurchaseOrderService) WithContext(ctx context.Context) *PurchaseOrderService {
+	return &PurchaseOrderService{DB: s.DB.WithContext(ctx), Inventory: s.Inventory.WithContext(ctx), Scope: s.Scope}
+}
+
+// WithScope returns a copy of the service limited to scope.
+func (s *PurchaseOrderService) WithScope(scope *WarehouseScope) *PurchaseOrderService {
+	return &PurchaseOrderService{DB: s.DB, Inventory: s.Inventory.WithScope(scope), Scope: scope}
+}
+
+// Create records a pending purchase order.
+func (s *PurchaseOrderService) Create(supplierID, warehouseID uuid.UUID, lines []OrderLine) (*models.PurchaseOrder, error) {
+	if err := s.Scope.Check(warehouseID); err != nil {
+		return nil, err
+	}
+
+	po := models.PurchaseOrder{
+		SupplierID:  supplierID,
+		WarehouseID: warehouseID,
+		Status:      POStatusPending,
+		Date:        time.Now(),
+	}
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		po.TotalAmount += float64(line.Quantity) * line.UnitPrice
+		po.Items = append(po.Items, models.PurchaseOrderItem{
+			ItemID:    line.ItemID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+	}
+
+	if err := s.DB.Create(&po).Error; err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// Get loads a purchase order with its lines. Orders outside the scope are
+// not found.
+func (s *PurchaseOrderService) Get(id uuid.UUID) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	if err := s.DB.Scopes(s.Scope.Filter("warehouse_id")).Preload("Items").First(&po, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &po, nil
+}
+
+// UpdateStatus changes the status of a purchase order. Moving it to
+// Received puts every line into stock in the same transaction, as receipts
+// by userID. It fails with ErrConflict if the order is no longer at version,
+// unless version is zero, or changes meanwhile, so it is received only once.
+func (s *PurchaseOrderService) UpdateStatus(id uuid.UUID, status string, version int, userID uuid.UUID) (*models.PurchaseOrder, error) {
+	switch status {
+	case POStatusPending, POStatusReceived, POStatusCancelled:
+	default:
+		return nil, ErrInvalidStatus
+	}
+
+	po, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(po, version); err != nil {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if status == POStatusReceived && po.Status != POStatusReceived {
+			inv := s.Inventory.WithTx(tx)
+			receipt := Movement{ReferenceType: ReferencePurchaseOrder, ReferenceID: &po.ID, UserID: &userID}
+			for _, item := range po.Items {
+				if _, err := inv.Receive(item.ItemID, po.WarehouseID, item.Quantity, receipt); err != nil {
+					return err
+				}
+			}
+		}
+
+		po.Status = status
+		return updateVersioned(tx, po)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// Delete removes a purchase order.
+func (s *PurchaseOrderService) Delete(id uuid.UUID) error {
+	result := s.DB.Scopes(s.Scope.Filter("warehouse_id")).Delete(&models.PurchaseOrder{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
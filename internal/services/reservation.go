@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reservation statuses.
+const (
+	ReservationActive    = "active"
+	ReservationFulfilled = "fulfilled"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+var (
+	ErrReservationInactive = errors.New("reservation is no longer active")
+	ErrReservationMismatch = errors.New("reservation is for another item or warehouse")
+	ErrStockReserved       = errors.New("stock has active reservations")
+)
+
+// Reserve holds quantity of an item in a warehouse for userID until ttl
+// passes. Held stock stays on hand but can no longer be sold or reserved
+// by anyone else.
+func (s *InventoryService) Reserve(itemID, warehouseID uuid.UUID, quantity int, ttl time.Duration, userID uuid.UUID, note string) (*models.Reservation, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if err := s.Scope.Check(warehouseID); err != nil {
+		return nil, err
+	}
+
+	reservation := models.Reservation{
+		ItemID:      itemID,
+		WarehouseID: warehouseID,
+		Quantity:    quantity,
+		Status:      ReservationActive,
+		ExpiresAt:   time.Now().Add(ttl),
+		UserID:      userID,
+		Note:        note,
+	}
+
+	err := retryOnConflict(func() error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			inventory, err := s.WithTx(tx).Find(itemID, warehouseID)
+			if errors.Is(err, ErrNotFound) {
+				return ErrInsufficientStock
+			}
+			if err != nil {
+				return err
+			}
+			if inventory.Available < quantity {
+				return ErrInsufficientStock
+			}
+
+			inventory.Reserved += quantity
+			if err := updateVersioned(tx, inventory); err != nil {
+				return err
+			}
+			return tx.Create(&reservation).Error
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetReservation returns a reservation. Reservations outside the scope are
+// not found.
+func (s *InventoryService) GetReservation(id uuid.UUID) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := s.DB.Scopes(s.Scope.Filter("warehouse_id")).First(&reservation, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Release cancels an active reservation, making its stock available again.
+func (s *InventoryService) Release(id uuid.UUID) (*models.Reservation, error) {
+	var reservation *models.Reservation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if reservation, err = s.WithTx(tx).GetReservation(id); err != nil {
+			return err
+		}
+		if reservation.Status != ReservationActive {
+			return ErrReservationInactive
+		}
+		return s.WithTx(tx).unreserve(reservation, ReservationReleased, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Fulfil turns a reservation into part of an order: the held stock is
+// released so that the order can issue it. It must be active, unexpired,
+// and for the item and warehouse being sold.
+func (s *InventoryService) Fulfil(id, itemID, warehouseID, orderID uuid.UUID) error {
+	reservation, err := s.GetReservation(id)
+	if err != nil {
+		return err
+	}
+	if reservation.Status != ReservationActive || !reservation.ExpiresAt.After(time.Now()) {
+		return ErrReservationInactive
+	}
+	if reservation.ItemID != itemID || reservation.WarehouseID != warehouseID {
+		return ErrReservationMismatch
+	}
+	return s.unreserve(reservation, ReservationFulfilled, &orderID)
+}
+
+// ReleaseExpired expires every active reservation whose time is up and
+// returns how many it released.
+func (s *InventoryService) ReleaseExpired() (int, error) {
+	var expired []models.Reservation
+	err := s.DB.Where("status = ? AND expires_at <= ?", ReservationActive, time.Now()).Find(&expired).Error
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for i := range expired {
+		err := s.unreserve(&expired[i], ReservationExpired, nil)
+		if errors.Is(err, ErrReservationInactive) {
+			continue // fulfilled or released meanwhile
+		}
+		if err != nil {
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
+
+// SweepReservations releases expired reservations every interval until ctx
+// is cancelled.
+func (s *InventoryService) SweepReservations(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := s.WithContext(ctx).ReleaseExpired()
+			if err != nil {
+				log.Println("Failed to release expired reservations:", err)
+			}
+			if released > 0 {
+				log.Println("Released", released, "expired reservations")
+			}
+		}
+	}
+}
+
+// unreserve closes an active reservation with status and returns its
+// quantity to the available stock. Of two requests closing the same
+// reservation, the second fails with ErrReservationInactive.
+func (s *InventoryService) unreserve(reservation *models.Reservation, status string, orderID *uuid.UUID) error {
+	return retryOnConflict(func() error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(reservation).Where("status = ?", ReservationActive).
+				Updates(map[string]interface{}{"status": status, "order_id": orderID})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrReservationInactive
+			}
+			reservation.Status, reservation.OrderID = status, orderID
+
+			inventory, err := s.WithTx(tx).Find(reservation.ItemID, reservation.WarehouseID)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			inventory.Reserved = max(inventory.Reserved-reservation.Quantity, 0)
+			return updateVersioned(tx, inventory)
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReservedStockCannotBeSold(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 5)
+
+	reservation, err := inventory.Reserve(stock.ItemID, stock.WarehouseID, 4, time.Hour, uuid.New(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 2, Movement{}); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("selling reserved stock: err = %v, want ErrInsufficientStock", err)
+	}
+	if _, err := inventory.Reserve(stock.ItemID, stock.WarehouseID, 2, time.Hour, uuid.New(), ""); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("reserving reserved stock: err = %v, want ErrInsufficientStock", err)
+	}
+
+	if _, err := inventory.Release(reservation.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventory.Release(reservation.ID); !errors.Is(err, ErrReservationInactive) {
+		t.Errorf("releasing twice: err = %v, want ErrReservationInactive", err)
+	}
+	if _, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 5, Movement{}); err != nil {
+		t.Errorf("selling released stock: %v", err)
+	}
+}
+
+func TestFulfilReleasesStockForTheOrder(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 5)
+	reservation, err := inventory.Reserve(stock.ItemID, stock.WarehouseID, 5, time.Hour, uuid.New(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := inventory.Fulfil(reservation.ID, uuid.New(), stock.WarehouseID, uuid.New()); !errors.Is(err, ErrReservationMismatch) {
+		t.Fatalf("fulfilling for another item: err = %v, want ErrReservationMismatch", err)
+	}
+	if err := inventory.Fulfil(reservation.ID, stock.ItemID, stock.WarehouseID, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventory.Issue(stock.ItemID, stock.WarehouseID, 5, Movement{}); err != nil {
+		t.Errorf("issuing fulfilled stock: %v", err)
+	}
+	if err := inventory.Fulfil(reservation.ID, stock.ItemID, stock.WarehouseID, uuid.New()); !errors.Is(err, ErrReservationInactive) {
+		t.Errorf("fulfilling twice: err = %v, want ErrReservationInactive", err)
+	}
+}
+
+func TestReleaseExpired(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 5)
+	expired, err := inventory.Reserve(stock.ItemID, stock.WarehouseID, 2, -time.Minute, uuid.New(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventory.Reserve(stock.ItemID, stock.WarehouseID, 3, time.Hour, uuid.New(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	released, err := inventory.ReleaseExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if released != 1 {
+		t.Errorf("released %d reservations, want 1", released)
+	}
+	if err := inventory.Fulfil(expired.ID, stock.ItemID, stock.WarehouseID, uuid.New()); !errors.Is(err, ErrReservationInactive) {
+		t.Errorf("fulfilling an expired reservation: err = %v, want ErrReservationInactive", err)
+	}
+	after, err := inventory.Get(stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Reserved != 3 || after.Available != 2 {
+		t.Errorf("reserved %d, available %d; want 3 and 2", after.Reserved, after.Available)
+	}
+}
+
+func TestConcurrentReservationsNeverOverbook(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	stock := receiveStock(t, db, 5)
+	interleave(t, db)
+
+	held := 0
+	for _, err := range concurrently(20, func() error {
+		_, err := inventory.Reserve(stock.ItemID, stock.WarehouseID, 1, time.Hour, uuid.New(), "")
+		return err
+	}) {
+		switch {
+		case err == nil:
+			held++
+		case !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, ErrConflict):
+			t.Fatal(err)
+		}
+	}
+
+	after, err := inventory.Get(stock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held > 5 || after.Reserved != held {
+		t.Errorf("%d reservations hold %d of 5", held, after.Reserved)
+	}
+}
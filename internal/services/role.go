@@ -0,0 +1,664 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rest/internal/models"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleCycle        = errors.New("a role cannot inherit from itself or its descendants")
+	ErrRoleNameTaken    = errors.New("role name is already taken")
+	ErrRoleInUse        = errors.New("role is assigned to users or inherited by other roles")
+	ErrPermissionExists = errors.New("permission already exists")
+	ErrInvalidRole      = errors.New("invalid role")
+)
+
+// maxRoleDepth bounds how far inheritance is followed, so a cycle written
+// straight to the database cannot hang a request.
+const maxRoleDepth = 16
+
+// ResolvedRole is a role together with everything it inherits.
+type ResolvedRole struct {
+	Role models.Role
+	// Permissions holds the "resource:action" names of the role and its
+	// ancestors.
+	Permissions map[string]bool
+	// RequireMFA is set if the role or any ancestor requires MFA.
+	RequireMFA bool
+	// Lineage is the role's ID followed by its ancestors', nearest first.
+	Lineage []uuid.UUID
+}
+
+// Inherits reports whether the role is id or descends from it.
+func (r *ResolvedRole) Inherits(id uuid.UUID) bool {
+	for _, ancestor := range r.Lineage {
+		if ancestor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveRole loads a role and its ancestors and merges what they grant.
+func ResolveRole(db *gorm.DB, id uuid.UUID) (*ResolvedRole, error) {
+	resolved := &ResolvedRole{Permissions: make(map[string]bool)}
+
+	next := &id
+	for depth := 0; next != nil && depth < maxRoleDepth; depth++ {
+		if resolved.Inherits(*next) {
+			break
+		}
+
+		var role models.Role
+		if err := db.Preload("Permissions").First(&role, "id = ?", *next).Error; err != nil {
+			if depth > 0 && errors.Is(err, gorm.ErrRecordNotFound) {
+				break // a deleted parent grants nothing
+			}
+			return nil, err
+		}
+		if depth == 0 {
+			resolved.Role = role
+		}
+
+		resolved.Lineage = append(resolved.Lineage, role.ID)
+		resolved.RequireMFA = resolved.RequireMFA || role.RequireMFA
+		for _, p := range role.Permissions {
+			resolved.Permissions[p.Resource+":"+p.Action] = true
+		}
+		next = role.ParentID
+	}
+	return resolved, nil
+}
+
+// RoleService manages roles, their permissions and inheritance, keeping
+// the permission cache in step.
+type RoleService struct {
+	DB    *gorm.DB
+	Cache *PermissionCache
+}
+
+func NewRoleService(db *gorm.DB, cache *PermissionCache) *RoleService {
+	return &RoleService{DB: db, Cache: cache}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx.
+func (s *RoleService) WithContext(ctx context.Context) *RoleService {
+	return &RoleService{DB: s.DB.WithContext(ctx), Cache: s.Cache}
+}
+
+// RoleInput is what a client may set on a role. Parent and permissions
+// have their own methods.
+type RoleInput struct {
+	Name        *string
+	Description *string
+	RequireMFA  *bool
+}
+
+// Create creates a role, optionally inheriting from parentID.
+func (s *RoleService) Create(input RoleInput, parentID *uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	if input.Name != nil {
+		role.Name = *input.Name
+	}
+	if input.Description != nil {
+		role.Description = *input.Description
+	}
+	if input.RequireMFA != nil {
+		role.RequireMFA = *input.RequireMFA
+	}
+	role.ParentID = parentID
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkRoleName(tx, role.Name, uuid.Nil); err != nil {
+			return err
+		}
+		if parentID != nil {
+			if err := checkRoles(tx, []uuid.UUID{*parentID}); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Permissions").Create(&role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(role.ID)
+}
+
+// Get returns a role with its own permissions.
+func (s *RoleService) Get(id uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	if err := s.DB.Preload("Permissions").First(&role, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Update changes a role's name, description or MFA requirement.
+func (s *RoleService) Update(id uuid.UUID, input RoleInput) (*models.Role, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.First(&role, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if input.Name != nil {
+			if err := checkRoleName(tx, *input.Name, role.ID); err != nil {
+				return err
+			}
+			updates["name"] = *input.Name
+		}
+		if input.Description != nil {
+			updates["description"] = *input.Description
+		}
+		if input.RequireMFA != nil {
+			updates["require_mfa"] = *input.RequireMFA
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&role).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cache.Invalidate(id)
+	return s.Get(id)
+}
+
+// Delete removes a role that no user holds and no role inherits from.
+func (s *RoleService) Delete(id uuid.UUID) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.First(&role, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var holders, children int64
+		if err := tx.Model(&models.UserRole{}).Where("role_id = ?", id).Count(&holders).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Role{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if holders > 0 || children > 0 {
+			return ErrRoleInUse
+		}
+
+		if err := tx.Where("role_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		// Hard delete, so the name can be used again
+		return tx.Unscoped().Delete(&role).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Cache.Invalidate(id)
+	return nil
+}
+
+// SetPermissions replaces a role's own permissions. Every ID must name an
+// existing permission, and the last admin cannot lose every permission.
+func (s *RoleService) SetPermissions(id uuid.UUID, permissionIDs []uuid.UUID) (*models.Role, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Role{}, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		unique := uniqueIDs(permissionIDs)
+		var found []uuid.UUID
+		if len(unique) > 0 {
+			if err := tx.Model(&models.Permission{}).Where("id IN ?", permissionIDs).Pluck("id", &found).Error; err != nil {
+				return err
+			}
+		}
+		if len(found) != len(unique) {
+			for _, id := range found {
+				delete(unique, id)
+			}
+			for missing := range unique {
+				return fmt.Errorf("%w: %s", ErrUnknownPermission, missing)
+			}
+		}
+
+		return guardLastAdmin(tx, func() error {
+			return setRolePermissions(tx, id, found)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cache.Invalidate(id)
+	return s.Get(id)
+}
+
+// CreatePermission adds a permission. It must be well formed and allow at
+// least one permission in the catalog, so typos are caught.
+func (s *RoleService) CreatePermission(resource, action string) (*models.Permission, error) {
+	if err := checkPermission(resource, action); err != nil {
+		return nil, err
+	}
+
+	permission := models.Permission{Resource: resource, Action: action}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkPermissionFree(tx, resource, action, uuid.Nil); err != nil {
+			return err
+		}
+		return tx.Create(&permission).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// GetPermission returns a permission.
+func (s *RoleService) GetPermission(id uuid.UUID) (*models.Permission, error) {
+	var permission models.Permission
+	if err := s.DB.First(&permission, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// UpdatePermission changes what a permission grants, for every role that
+// holds it.
+func (s *RoleService) UpdatePermission(id uuid.UUID, resource, action string) (*models.Permission, error) {
+	if err := checkPermission(resource, action); err != nil {
+		return nil, err
+	}
+
+	var permission models.Permission
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&permission, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := checkPermissionFree(tx, resource, action, id); err != nil {
+			return err
+		}
+
+		return guardLastAdmin(tx, func() error {
+			permission.Resource, permission.Action = resource, action
+			return tx.Model(&permission).Updates(map[string]interface{}{"resource": resource, "action": action}).Error
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cache.InvalidateAll()
+	return &permission, nil
+}
+
+// DeletePermission removes a permission from every role and API key that
+// holds it.
+func (s *RoleService) DeletePermission(id uuid.UUID) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var permission models.Permission
+		if err := tx.First(&permission, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		return guardLastAdmin(tx, func() error {
+			if err := tx.Where("permission_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Table("api_key_permissions").Where("permission_id = ?", id).Delete(nil).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&permission).Error
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Cache.InvalidateAll()
+	return nil
+}
+
+// defaultRoles are created by Seed when missing, each inheriting from the
+// one before it.
+var defaultRoles = []struct {
+	Name        string
+	Description string
+	Permissions []string
+}{
+	{"viewer", "Read-only access to the catalogue, stock, purchasing and reports", []string{
+		"items:read", "categories:read", "warehouses:read", "suppliers:read",
+		"discounts:read", "inventory:read", "purchase_orders:read", "reports:read",
+	}},
+	{"clerk", "Receives, moves and sells stock in assigned warehouses", []string{
+		"inventory:write", "orders:write", "reviews:write", "favorites:write",
+	}},
+	{"manager", "Runs the catalogue, suppliers and purchasing across every warehouse", []string{
+		"items:*", "categories:*", "warehouses:*", "suppliers:*", "discounts:*",
+		"inventory:*", "purchase_orders:*", "orders:*", "reports:*", "all_warehouses:access",
+	}},
+}
+
+// Seed creates a permission row for everything in the catalog and, when
+// defaults is set, the admin role and the default roles. It only adds what
+// is missing, so roles an administrator has changed are left alone.
+func (s *RoleService) Seed(adminRole string, defaults bool) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, name := range PermissionCatalog() {
+			if _, err := findOrCreatePermission(tx, name); err != nil {
+				return err
+			}
+		}
+		if !defaults {
+			return nil
+		}
+
+		if _, err := seedRole(tx, adminRole, "Every permission", nil, []string{"*:*"}); err != nil {
+			return err
+		}
+		var parentID *uuid.UUID
+		for _, def := range defaultRoles {
+			role, err := seedRole(tx, def.Name, def.Description, parentID, def.Permissions)
+			if err != nil {
+				return err
+			}
+			parentID = &role.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Cache.InvalidateAll()
+	return nil
+}
+
+// seedRole returns the named role, creating it with the given permissions
+// if it does not exist.
+func seedRole(tx *gorm.DB, name, description string, parentID *uuid.UUID, permissions []string) (*models.Role, error) {
+	var role models.Role
+	err := tx.Where("name = ?", name).First(&role).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return &role, err
+	}
+
+	role = models.Role{Name: name, Description: description, ParentID: parentID}
+	if err := tx.Create(&role).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(permissions))
+	for _, name := range permissions {
+		permission, err := findOrCreatePermission(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, permission.ID)
+	}
+	return &role, setRolePermissions(tx, role.ID, ids)
+}
+
+func findOrCreatePermission(tx *gorm.DB, name string) (*models.Permission, error) {
+	resource, action, _ := strings.Cut(name, ":")
+	var permission models.Permission
+	err := tx.Where(models.Permission{Resource: resource, Action: action}).FirstOrCreate(&permission).Error
+	return &permission, err
+}
+
+// setRolePermissions replaces a role's permissions through the join table;
+// association mode would upsert the permissions, and Base hands every
+// upserted row a fresh ID.
+func setRolePermissions(tx *gorm.DB, roleID uuid.UUID, permissionIDs []uuid.UUID) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for id := range uniqueIDs(permissionIDs) {
+		if err := tx.Create(&models.RolePermission{RoleID: roleID, PermissionID: id}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkRoleName(tx *gorm.DB, name string, except uuid.UUID) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRole)
+	}
+	var taken int64
+	if err := tx.Model(&models.Role{}).Where("name = ? AND id <> ?", name, except).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrRoleNameTaken
+	}
+	return nil
+}
+
+func checkPermission(resource, action string) error {
+	if err := ValidatePermission(resource, action); err != nil {
+		return err
+	}
+	if !inCatalog(resource, action) {
+		return fmt.Errorf("%w: %s:%s matches nothing the API checks", ErrUnknownPermission, resource, action)
+	}
+	return nil
+}
+
+func checkPermissionFree(tx *gorm.DB, resource, action string, except uuid.UUID) error {
+	var taken int64
+	err := tx.Model(&models.Permission{}).
+		Where("resource = ? AND action = ? AND id <> ?", resource, action, except).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrPermissionExists
+	}
+	return nil
+}
+
+// SetParent makes a role inherit from parentID, or from nothing if it is
+// nil. It refuses cycles and changes that would leave no admin.
+func (s *RoleService) SetParent(id uuid.UUID, parentID *uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&role, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if parentID != nil {
+			roles, err := loadRoles(tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := roles[*parentID]; !ok {
+				return ErrNotFound
+			}
+			for _, ancestor := range lineage(roles, *parentID) {
+				if ancestor.ID == id {
+					return ErrRoleCycle
+				}
+			}
+		}
+
+		return guardLastAdmin(tx, func() error {
+			role.ParentID = parentID
+			return tx.Model(&role).Update("parent_id", parentID).Error
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cache.Invalidate(role.ID)
+	return &role, nil
+}
+
+// loadRoles returns every role with its permissions, by ID.
+func loadRoles(tx *gorm.DB) (map[uuid.UUID]models.Role, error) {
+	var list []models.Role
+	if err := tx.Preload("Permissions").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	roles := make(map[uuid.UUID]models.Role, len(list))
+	for _, role := range list {
+		roles[role.ID] = role
+	}
+	return roles, nil
+}
+
+// lineage returns a role followed by its ancestors, nearest first.
+func lineage(roles map[uuid.UUID]models.Role, id uuid.UUID) []models.Role {
+	var chain []models.Role
+	seen := make(map[uuid.UUID]bool)
+
+	next := &id
+	for next != nil && !seen[*next] && len(chain) < maxRoleDepth {
+		role, ok := roles[*next]
+		if !ok {
+			break
+		}
+		seen[role.ID] = true
+		chain = append(chain, role)
+		next = role.ParentID
+	}
+	return chain
+}
+
+// adminRoleIDs returns the roles that grant every permission, directly or
+// by inheritance.
+func adminRoleIDs(tx *gorm.DB) ([]uuid.UUID, error) {
+	roles, err := loadRoles(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	for id := range roles {
+	chain:
+		for _, role := range lineage(roles, id) {
+			for _, p := range role.Permissions {
+				if p.Resource == "*" && p.Action == "*" {
+					ids = append(ids, id)
+					break chain
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+// countAdmins counts the enabled users holding an admin role.
+func countAdmins(tx *gorm.DB) (int64, error) {
+	ids, err := adminRoleIDs(tx)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	var count int64
+	err = tx.Model(&models.User{}).
+		Where("disabled = ?", false).
+		Where("id IN (?)", tx.Model(&models.UserRole{}).Select("user_id").Where("role_id IN ?", ids)).
+		Count(&count).Error
+	return count, err
+}
+
+// guardLastAdmin runs change inside tx and fails with ErrLastAdmin if it
+// took away the last enabled admin.
+func guardLastAdmin(tx *gorm.DB, change func() error) error {
+	before, err := countAdmins(tx)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	if before == 0 {
+		return nil
+	}
+
+	after, err := countAdmins(tx)
+	if err != nil {
+		return err
+	}
+	if after == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// PermissionSource says which of a user's roles grants a permission, and
+// which ancestor of it holds the permission when it is inherited.
+type PermissionSource struct {
+	Permission    string `json:"permission"`
+	Role          string `json:"role"`
+	InheritedFrom string `json:"inherited_from,omitempty"`
+}
+
+// PermissionSources lists every permission the given roles grant and where
+// each one comes from, ordered by permission.
+func PermissionSources(tx *gorm.DB, roleIDs []uuid.UUID) ([]PermissionSource, error) {
+	roles, err := loadRoles(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []PermissionSource{}
+	for _, id := range roleIDs {
+		chain := lineage(roles, id)
+		for i, role := range chain {
+			for _, p := range role.Permissions {
+				source := PermissionSource{Permission: p.Resource + ":" + p.Action, Role: chain[0].Name}
+				if i > 0 {
+					source.InheritedFrom = role.Name
+				}
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Permission != sources[j].Permission {
+			return sources[i].Permission < sources[j].Permission
+		}
+		return sources[i].Role < sources[j].Role
+	})
+	return sources, nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"go-rest/internal/models"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Security event types.
+const (
+	EventLoginSucceeded  = "login_succeeded"
+	EventLoginFailed     = "login_failed"
+	EventLoginThrottled  = "login_throttled"
+	EventAccountLocked   = "account_locked"
+	EventAccountUnlocked = "account_unlocked"
+	EventIPLocked        = "ip_locked"
+
+	EventPasswordChanged        = "password_changed"
+	EventPasswordResetRequested = "password_reset_requested"
+	EventResetThrottled         = "password_reset_throttled"
+	EventPasswordReset          = "password_reset"
+
+	EventMFAEnabled  = "mfa_enabled"
+	EventMFADisabled = "mfa_disabled"
+	EventMFAFailed   = "mfa_failed"
+)
+
+// SecurityEventService records authentication events for review.
+type SecurityEventService struct {
+	DB *gorm.DB
+}
+
+func NewSecurityEventService(db *gorm.DB) *SecurityEventService {
+	return &SecurityEventService{DB: db}
+}
+
+// Record stores an event. Failures are logged rather than returned so that
+// auditing never blocks the request that triggered it.
+func (s *SecurityEventService) Record(eventType string, userID *uuid.UUID, username, ip, details string) {
+	event := models.SecurityEvent{
+		Type:     eventType,
+		UserID:   userID,
+		Username: username,
+		IP:       ip,
+		Details:  details,
+	}
+	if err := s.DB.Create(&event).Error; err != nil {
+		log.Println("Failed to record security event:", err)
+	}
+}
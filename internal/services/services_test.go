@@ -0,0 +1,90 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-rest/internal/config"
+	"go-rest/internal/database"
+	"go-rest/internal/database/migrations"
+	"go-rest/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns a migrated database of its own for a test, opened as
+// the server opens SQLite.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dialector, err := database.Dialector("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// seedRoles creates the admin role and the default roles.
+func seedRoles(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	roles := NewRoleService(db, NewPermissionCache(db, 0))
+	if err := roles.Seed(config.Default().Registration.AdminRole, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func roleID(t *testing.T, db *gorm.DB, name string) uuid.UUID {
+	t.Helper()
+	var role models.Role
+	if err := db.First(&role, "name = ?", name).Error; err != nil {
+		t.Fatal(err)
+	}
+	return role.ID
+}
+
+// createUser creates a user with the named roles.
+func createUser(t *testing.T, db *gorm.DB, username string, roles ...string) *models.User {
+	t.Helper()
+	input := NewUser{Username: username, Password: "Secret-Passw0rd", Email: username + "@example.com"}
+	for _, name := range roles {
+		input.RoleIDs = append(input.RoleIDs, roleID(t, db, name))
+	}
+	user, err := NewUserService(db, config.Default(), NewPermissionCache(db, 0)).Create(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// rolePermissions returns what the named role grants, with inheritance.
+func rolePermissions(t *testing.T, db *gorm.DB, name string) map[string]bool {
+	t.Helper()
+	role, err := ResolveRole(db, roleID(t, db, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	held := make(map[string]bool, len(role.Permissions))
+	for p := range role.Permissions {
+		held[p] = true
+	}
+	return held
+}
+
+// receiveStock creates a stock record for a new item and warehouse holding
+// quantity.
+func receiveStock(t *testing.T, db *gorm.DB, quantity int) *models.Inventory {
+	t.Helper()
+	inventory, err := NewInventoryService(db).Receive(uuid.New(), uuid.New(), quantity, Movement{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inventory
+}
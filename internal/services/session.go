@@ -0,0 +1,252 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrTokenReused    = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// TokenPair is returned on login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// AccessClaims are the claims carried by an access token.
+type AccessClaims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	// MFA is set when the session was confirmed with a second factor.
+	MFA bool
+}
+
+// SessionService issues short-lived access tokens and rotating refresh tokens.
+type SessionService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Tokens *TokenService
+}
+
+func NewSessionService(db *gorm.DB, cfg *config.Config, tokens *TokenService) *SessionService {
+	return &SessionService{DB: db, Config: cfg, Tokens: tokens}
+}
+
+// Start opens a new session for user and returns its first token pair. mfa
+// records whether the login was confirmed with a second factor.
+func (s *SessionService) Start(userID uuid.UUID, userAgent, ip string, mfa bool) (*TokenPair, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:    userID,
+		ExpiresAt: now.Add(s.Config.RefreshTokenTTL),
+		UserAgent: userAgent,
+		IP:        ip,
+		MFA:       mfa,
+	}
+
+	var pair *TokenPair
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		raw, _, err := s.createRefreshToken(tx, &session, now)
+		if err != nil {
+			return err
+		}
+
+		pair, err = s.pair(&session, raw)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token is
+// used up; presenting it again revokes the whole session.
+func (s *SessionService) Refresh(raw string) (*TokenPair, error) {
+	now := time.Now()
+
+	var token models.RefreshToken
+	if err := s.DB.Where("token_hash = ?", hashToken(raw)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if token.UsedAt != nil {
+		if err := s.Revoke(token.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+	if now.After(token.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	var session models.Session
+	if err := s.DB.First(&session, "id = ?", token.SessionID).Error; err != nil {
+		return nil, ErrInvalidToken
+	}
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return nil, ErrSessionRevoked
+	}
+
+	var pair *TokenPair
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		newRaw, next, err := s.createRefreshToken(tx, &session, now)
+		if err != nil {
+			return err
+		}
+
+		// Only one caller can use a token; a concurrent loser is a reuse.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Updates(map[string]interface{}{"used_at": now, "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenReused
+		}
+
+		pair, err = s.pair(&session, newRaw)
+		return err
+	})
+	if errors.Is(err, ErrTokenReused) {
+		if revokeErr := s.Revoke(token.SessionID); revokeErr != nil {
+			return nil, revokeErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Revoke ends a session and every token issued for it.
+func (s *SessionService) Revoke(sessionID uuid.UUID) error {
+	return s.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAll ends every session of a user except the one given, if any.
+func (s *SessionService) RevokeAll(userID uuid.UUID, except uuid.UUID) error {
+	return s.DB.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, except).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Active reports whether a session may still be used.
+func (s *SessionService) Active(sessionID uuid.UUID) (bool, error) {
+	var count int64
+	err := s.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ParseAccessToken validates an access token and returns its claims.
+func (s *SessionService) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims, err := s.Tokens.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if typ, _ := claims["typ"].(string); typ != "access" {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := uuidClaim(claims, "user_id")
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := uuidClaim(claims, "sid")
+	if err != nil {
+		return nil, err
+	}
+	mfa, _ := claims["mfa"].(bool)
+	return &AccessClaims{UserID: userID, SessionID: sessionID, MFA: mfa}, nil
+}
+
+func (s *SessionService) pair(session *models.Session, refresh string) (*TokenPair, error) {
+	access, err := s.Tokens.Sign(jwt.MapClaims{
+		"typ":     "access",
+		"user_id": session.UserID.String(),
+		"sid":     session.ID.String(),
+		"mfa":     session.MFA,
+	}, s.Config.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(s.Config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *SessionService) createRefreshToken(tx *gorm.DB, session *models.Session, now time.Time) (string, *models.RefreshToken, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := models.RefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(s.Config.RefreshTokenTTL),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+	return raw, &token, nil
+}
+
+func uuidClaim(claims jwt.MapClaims, name string) (uuid.UUID, error) {
+	value, ok := claims[name].(string)
+	if !ok {
+		return uuid.Nil, ErrInvalidToken
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return id, nil
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how opaque tokens are stored, so a database leak does not
+// leak usable tokens.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
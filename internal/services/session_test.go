@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-rest/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newTestSessions(t *testing.T, secret string) *SessionService {
+	t.Helper()
+	cfg := config.Default()
+	cfg.JWT.Secret = secret
+	tokens, err := NewTokenService(cfg.JWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSessionService(newTestDB(t), cfg, tokens)
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	sessions := newTestSessions(t, "test-secret")
+	userID := uuid.New()
+	first, err := sessions.Start(userID, "test", "192.0.2.1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := sessions.ParseAccessToken(first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != userID || !claims.MFA {
+		t.Errorf("claims = %+v, want user %s with MFA", claims, userID)
+	}
+
+	second, err := sessions.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Refresh(first.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reusing a refresh token: err = %v, want ErrTokenReused", err)
+	}
+	if active, err := sessions.Active(claims.SessionID); err != nil || active {
+		t.Errorf("session active = %v after reuse (err %v), want revoked", active, err)
+	}
+	if _, err := sessions.Refresh(second.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("refreshing a revoked session: err = %v, want ErrSessionRevoked", err)
+	}
+}
+
+func TestConcurrentRefreshesIssueOnePair(t *testing.T) {
+	sessions := newTestSessions(t, "test-secret")
+	pair, err := sessions.Start(uuid.New(), "test", "192.0.2.1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed := 0
+	for _, err := range concurrently(20, func() error {
+		_, err := sessions.Refresh(pair.RefreshToken)
+		return err
+	}) {
+		switch {
+		case err == nil:
+			refreshed++
+		case !errors.Is(err, ErrTokenReused) && !errors.Is(err, ErrSessionRevoked):
+			t.Fatal(err)
+		}
+	}
+	if refreshed > 1 {
+		t.Errorf("one refresh token was exchanged %d times", refreshed)
+	}
+}
+
+func TestParseAccessTokenRejects(t *testing.T) {
+	sessions := newTestSessions(t, "test-secret")
+	claims := jwt.MapClaims{"typ": "access", "user_id": uuid.NewString(), "sid": uuid.NewString()}
+
+	valid, err := sessions.Tokens.Sign(claims, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.ParseAccessToken(valid); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	expired, err := sessions.Tokens.Sign(claims, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongType, err := sessions.Tokens.Sign(jwt.MapClaims{"typ": "mfa", "user_id": claims["user_id"], "sid": claims["sid"]}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := newTestSessions(t, "another-secret").Tokens.Sign(claims, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"expired":     expired,
+		"wrong type":  wrongType,
+		"wrong key":   foreign,
+		"unsigned":    unsigned,
+		"tampered":    valid[:len(valid)-2] + "xx",
+		"not a token": "not-a-token",
+	} {
+		if _, err := sessions.ParseAccessToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-rest/internal/config"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// verificationKey is a key accepted when verifying tokens.
+type verificationKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Key    interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// TokenService signs and verifies every JWT the server issues. The current
+// key signs; it and any retired keys verify, selected by the kid header.
+type TokenService struct {
+	Issuer   string
+	Audience string
+
+	signingKeyID string
+	signingKey   interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey
+	method       jwt.SigningMethod
+	keys         map[string]verificationKey
+}
+
+func NewTokenService(cfg config.JWTConfig) (*TokenService, error) {
+	s := &TokenService{
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		keys:     make(map[string]verificationKey),
+	}
+
+	var public interface{}
+	switch cfg.Algorithm {
+	case "HS256":
+		s.method = jwt.SigningMethodHS256
+		s.signingKey = []byte(cfg.Secret)
+		public = []byte(cfg.Secret)
+	case "RS256", "EdDSA":
+		pemData, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Algorithm == "RS256" {
+			key, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+			if err != nil {
+				return nil, err
+			}
+			s.method, s.signingKey, public = jwt.SigningMethodRS256, key, &key.PublicKey
+		} else {
+			key, err := jwt.ParseEdPrivateKeyFromPEM(pemData)
+			if err != nil {
+				return nil, err
+			}
+			edKey := key.(ed25519.PrivateKey)
+			s.method, s.signingKey, public = jwt.SigningMethodEdDSA, edKey, edKey.Public()
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+
+	s.signingKeyID = cfg.KeyID
+	if s.signingKeyID == "" {
+		s.signingKeyID = keyID(public)
+	}
+	s.keys[s.signingKeyID] = verificationKey{ID: s.signingKeyID, Method: s.method, Key: public}
+
+	for kid, path := range cfg.VerificationKeys {
+		key, err := loadVerificationKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		s.keys[kid] = key
+	}
+
+	return s, nil
+}
+
+// Sign issues a token carrying claims plus the standard iss, aud, iat, exp
+// and jti claims.
+func (s *TokenService) Sign(claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	all := jwt.MapClaims{
+		"iss": s.Issuer,
+		"aud": s.Audience,
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+		"jti": uuid.NewString(),
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+
+	token := jwt.NewWithClaims(s.method, all)
+	token.Header["kid"] = s.signingKeyID
+	return token.SignedString(s.signingKey)
+}
+
+// Parse verifies a token's signature and standard claims.
+func (s *TokenService) Parse(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.Key, nil
+	},
+		jwt.WithIssuer(s.Issuer),
+		jwt.WithAudience(s.Audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public verification keys. HMAC secrets are never published,
+// so the set is empty when only HS256 is in use.
+func (s *TokenService) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for kid, key := range s.keys {
+		switch pub := key.Key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256",
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Kid: kid, Use: "sig", Alg: "EdDSA", Crv: "Ed25519",
+				X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
+
+// loadVerificationKey reads a PEM public key, or a raw HMAC secret if the
+// file is not PEM.
+func loadVerificationKey(kid, path string) (verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return verificationKey{}, err
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "-----BEGIN") {
+		return verificationKey{ID: kid, Method: jwt.SigningMethodHS256, Key: []byte(strings.TrimSpace(string(data)))}, nil
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return verificationKey{ID: kid, Method: jwt.SigningMethodRS256, Key: key}, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return verificationKey{ID: kid, Method: jwt.SigningMethodEdDSA, Key: key.(ed25519.PublicKey)}, nil
+	}
+	return verificationKey{}, errors.New("unsupported verification key " + kid)
+}
+
+// keyID derives a stable key ID from the verification key.
+func keyID(public interface{}) string {
+	var data []byte
+	switch key := public.(type) {
+	case []byte:
+		data = append([]byte("hmac:"), key...)
+	case crypto.PublicKey:
+		data, _ = x509.MarshalPKIXPublicKey(key)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,584 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRegistrationClosed = errors.New("registration is by invitation only")
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrEmailTaken         = errors.New("email is already registered")
+	ErrLastAdmin          = errors.New("cannot remove the last active admin")
+	ErrUserOutranks       = errors.New("cannot change the email or roles of a user who has permissions you do not")
+)
+
+// NewUser is what a client may set when creating a user.
+type NewUser struct {
+	Username string
+	Password string
+	Email    string
+	// RoleIDs are only honoured for users created by an administrator;
+	// none means the default role.
+	RoleIDs []uuid.UUID
+}
+
+// UserUpdate holds the fields an administrator may change; nil fields are
+// left alone.
+type UserUpdate struct {
+	Email *string
+	// RoleIDs replaces the user's roles.
+	RoleIDs  *[]uuid.UUID
+	Disabled *bool
+	// WarehouseIDs replaces the user's warehouse assignments.
+	WarehouseIDs *[]uuid.UUID
+}
+
+// UserService manages users, deciding which role they start with and
+// keeping at least one admin. It tells Cache when a user's roles change.
+type UserService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Cache  *PermissionCache
+}
+
+func NewUserService(db *gorm.DB, cfg *config.Config, cache *PermissionCache) *UserService {
+	return &UserService{DB: db, Config: cfg, Cache: cache}
+}
+
+// WithContext returns a copy of the service whose queries carry ctx.
+func (s *UserService) WithContext(ctx context.Context) *UserService {
+	return &UserService{DB: s.DB.WithContext(ctx), Config: s.Config, Cache: s.Cache}
+}
+
+// Register creates a self-registered user. Any RoleIDs are ignored: the very
+// first user becomes an admin when bootstrapping is on, everyone else gets
+// the default role. In invite mode only that first user may register.
+//
+// The first user also writes the single AdminBootstrap row. When two
+// registrations both find no users, the loser fails on that row and starts
+// over, by which time it sees the winner and registers as a normal user.
+func (s *UserService) Register(input NewUser) (*models.User, error) {
+	hashed, err := s.hashPassword(input.Password, input.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = retryOnConflict(func() error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			input.RoleIDs = nil
+
+			var count int64
+			if err := tx.Model(&models.User{}).Unscoped().Count(&count).Error; err != nil {
+				return err
+			}
+			bootstrap := count == 0 && s.Config.Registration.BootstrapAdmin
+
+			if !bootstrap && s.Config.Registration.Mode == "invite" {
+				return ErrRegistrationClosed
+			}
+
+			if bootstrap {
+				admin, err := s.EnsureRole(tx, s.Config.Registration.AdminRole, true)
+				if err != nil {
+					return err
+				}
+				input.RoleIDs = []uuid.UUID{admin.ID}
+			}
+
+			var err error
+			if user, err = s.create(tx, input, hashed); err != nil || !bootstrap {
+				return err
+			}
+			err = tx.Create(&models.AdminBootstrap{ID: 1, UserID: user.ID}).Error
+			if err != nil && isDuplicate(tx, err) {
+				return ErrConflict
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Create creates a user on behalf of an administrator, with the requested
+// roles or the default one.
+func (s *UserService) Create(input NewUser) (*models.User, error) {
+	hashed, err := s.hashPassword(input.Password, input.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkRoles(tx, input.RoleIDs); err != nil {
+			return err
+		}
+
+		var err error
+		user, err = s.create(tx, input, hashed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateAdmin creates an admin, or adds the admin role to and re-enables
+// the user if the username is taken. It is how an operator recovers access from the
+// command line.
+func (s *UserService) CreateAdmin(input NewUser) (*models.User, error) {
+	// An existing user may keep their password; a new one needs one.
+	var hashed string
+	if input.Password != "" {
+		var err error
+		if hashed, err = s.hashPassword(input.Password, input.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	var user models.User
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		admin, err := s.EnsureRole(tx, s.Config.Registration.AdminRole, true)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("username = ?", input.Username).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if hashed == "" {
+				return ValidatePassword(s.Config.Password, input.Password, input.Username)
+			}
+			input.RoleIDs = []uuid.UUID{admin.ID}
+			created, err := s.create(tx, input, hashed)
+			if err != nil {
+				return err
+			}
+			user = *created
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := addRoles(tx, user.ID, admin.ID); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"disabled": false}
+		if hashed != "" {
+			updates["password"] = hashed
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Cache.InvalidateUser(user.ID)
+	return &user, nil
+}
+
+// Get returns a user with their roles and warehouses.
+func (s *UserService) Get(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.DB.Preload("Roles").Preload("Warehouses").First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Update changes a user on behalf of someone holding the permissions in
+// held. Changing the email, roles or disabled flag of a user who has
+// permissions outside held fails with ErrUserOutranks, so nobody can take
+// over, rewrite or lock out an account more powerful than their own. Disabling a user revokes their
+// sessions; neither disabling nor changing roles may leave the system
+// without an admin.
+func (s *UserService) Update(id uuid.UUID, input UserUpdate, held map[string]bool) (*models.User, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if input.Email != nil || input.RoleIDs != nil || input.Disabled != nil {
+			above, err := outranks(tx, user.ID, held)
+			if err != nil {
+				return err
+			}
+			if above {
+				return ErrUserOutranks
+			}
+		}
+
+		updates := map[string]interface{}{}
+		if input.Email != nil && (user.Email == nil || NormalizeEmail(*input.Email) != *user.Email) {
+			// The new address is unconfirmed, and reset links already
+			// mailed went to the old one.
+			updates["email_verified_at"] = nil
+			if err := tx.Model(&models.PasswordReset{}).
+				Where("user_id = ? AND used_at IS NULL", user.ID).
+				Update("used_at", time.Now()).Error; err != nil {
+				return err
+			}
+
+			email := NormalizeEmail(*input.Email)
+			if email == "" {
+				updates["email"] = nil
+			} else {
+				var taken int64
+				if err := tx.Model(&models.User{}).Unscoped().Where("email = ? AND id <> ?", email, user.ID).Count(&taken).Error; err != nil {
+					return err
+				}
+				if taken > 0 {
+					return ErrEmailTaken
+				}
+				updates["email"] = email
+			}
+		}
+
+		if input.Disabled != nil && *input.Disabled != user.Disabled {
+			updates["disabled"] = *input.Disabled
+		}
+
+		err := guardLastAdmin(tx, func() error {
+			if len(updates) > 0 {
+				if err := tx.Model(&user).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			if input.RoleIDs != nil {
+				return setRoles(tx, user.ID, *input.RoleIDs)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if input.WarehouseIDs != nil {
+			if err := s.assignWarehouses(tx, &user, *input.WarehouseIDs); err != nil {
+				return err
+			}
+		}
+		if input.Disabled != nil && *input.Disabled {
+			return revokeSessions(tx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if input.RoleIDs != nil {
+		s.Cache.InvalidateUser(id)
+	}
+	return s.Get(id)
+}
+
+// Delete removes a user on behalf of someone holding the permissions in
+// held, and revokes their sessions. A user who has permissions outside held
+// cannot be deleted, nor can the last admin.
+func (s *UserService) Delete(id uuid.UUID, held map[string]bool) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		above, err := outranks(tx, user.ID, held)
+		if err != nil {
+			return err
+		}
+		if above {
+			return ErrUserOutranks
+		}
+
+		err = guardLastAdmin(tx, func() error {
+			return tx.Delete(&user).Error
+		})
+		if err != nil {
+			return err
+		}
+		return revokeSessions(tx, user.ID)
+	})
+}
+
+// assignWarehouses replaces the warehouses a user is scoped to.
+func (s *UserService) assignWarehouses(tx *gorm.DB, user *models.User, ids []uuid.UUID) error {
+	warehouses := []models.Warehouse{}
+	if len(ids) > 0 {
+		if err := tx.Where("id IN ?", ids).Find(&warehouses).Error; err != nil {
+			return err
+		}
+	}
+	if len(warehouses) != len(uniqueIDs(ids)) {
+		return ErrNotFound
+	}
+
+	// Written through the join table: association mode would upsert the
+	// warehouses, and Base hands every upserted row a fresh ID.
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserWarehouse{}).Error; err != nil {
+		return err
+	}
+	for _, w := range warehouses {
+		if err := tx.Create(&models.UserWarehouse{UserID: user.ID, WarehouseID: w.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureRole returns the named role, creating it if missing. A created
+// superuser role gets the *:* permission.
+func (s *UserService) EnsureRole(tx *gorm.DB, name string, superuser bool) (*models.Role, error) {
+	var role models.Role
+	err := tx.Where("name = ?", name).First(&role).Error
+	if err == nil {
+		return &role, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	role = models.Role{Name: name}
+	if superuser {
+		var all models.Permission
+		if err := tx.Where(models.Permission{Resource: "*", Action: "*"}).FirstOrCreate(&all).Error; err != nil {
+			return nil, err
+		}
+		role.Description = "Every permission"
+		role.Permissions = []models.Permission{all}
+	}
+	if err := tx.Create(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// AddRole gives a user another role.
+func (s *UserService) AddRole(userID, roleID uuid.UUID) (*models.User, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.User{}, "id = ?", userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := checkRoles(tx, []uuid.UUID{roleID}); err != nil {
+			return err
+		}
+		return addRoles(tx, userID, roleID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Cache.InvalidateUser(userID)
+	return s.Get(userID)
+}
+
+// RemoveRole takes a role away from a user, unless that removes the last
+// admin.
+func (s *UserService) RemoveRole(userID, roleID uuid.UUID) (*models.User, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		return guardLastAdmin(tx, func() error {
+			result := tx.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRole{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrNotFound
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Cache.InvalidateUser(userID)
+	return s.Get(userID)
+}
+
+// PermissionSources lists a user's permissions and the roles they come
+// from.
+func (s *UserService) PermissionSources(userID uuid.UUID) ([]PermissionSource, error) {
+	if err := s.DB.First(&models.User{}, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	roleIDs, err := UserRoleIDs(s.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	return PermissionSources(s.DB, roleIDs)
+}
+
+// create inserts a user whose password has already been hashed, which is
+// too slow to do while holding a transaction.
+func (s *UserService) create(tx *gorm.DB, input NewUser, hashed string) (*models.User, error) {
+	var taken int64
+	if err := tx.Model(&models.User{}).Unscoped().Where("username = ?", input.Username).Count(&taken).Error; err != nil {
+		return nil, err
+	}
+	if taken > 0 {
+		return nil, ErrUsernameTaken
+	}
+
+	user := models.User{Username: input.Username, Password: hashed}
+	if email := NormalizeEmail(input.Email); email != "" {
+		if err := tx.Model(&models.User{}).Unscoped().Where("email = ?", email).Count(&taken).Error; err != nil {
+			return nil, err
+		}
+		if taken > 0 {
+			return nil, ErrEmailTaken
+		}
+		user.Email = &email
+	}
+
+	roleIDs := input.RoleIDs
+	if len(roleIDs) == 0 {
+		role, err := s.EnsureRole(tx, s.Config.Registration.DefaultRole, false)
+		if err != nil {
+			return nil, err
+		}
+		roleIDs = []uuid.UUID{role.ID}
+	}
+
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	if err := addRoles(tx, user.ID, roleIDs...); err != nil {
+		return nil, err
+	}
+	if err := tx.Preload("Roles").First(&user, "id = ?", user.ID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *UserService) hashPassword(password, username string) (string, error) {
+	if err := ValidatePassword(s.Config.Password, password, username); err != nil {
+		return "", err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// revokeSessions ends every session of a user inside tx.
+func revokeSessions(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func uniqueIDs(ids []uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// UserRoleIDs returns the IDs of the roles assigned to a user.
+func UserRoleIDs(db *gorm.DB, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Model(&models.UserRole{}).Where("user_id = ?", userID).Pluck("role_id", &ids).Error
+	return ids, err
+}
+
+// outranks reports whether a user's roles grant anything that held does not
+// cover.
+func outranks(tx *gorm.DB, userID uuid.UUID, held map[string]bool) (bool, error) {
+	roleIDs, err := UserRoleIDs(tx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range roleIDs {
+		role, err := ResolveRole(tx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		for name := range role.Permissions {
+			resource, action, _ := strings.Cut(name, ":")
+			if !HasPermission(held, resource, action) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkRoles fails with ErrNotFound unless every role exists.
+func checkRoles(tx *gorm.DB, roleIDs []uuid.UUID) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Role{}).Where("id IN ?", roleIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(uniqueIDs(roleIDs)) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// addRoles assigns roles to a user, skipping ones they already have. Join
+// rows are written directly: association mode would upsert the roles, and
+// Base hands every upserted row a fresh ID.
+func addRoles(tx *gorm.DB, userID uuid.UUID, roleIDs ...uuid.UUID) error {
+	existing, err := UserRoleIDs(tx, userID)
+	if err != nil {
+		return err
+	}
+	have := uniqueIDs(existing)
+
+	for _, id := range roleIDs {
+		if have[id] {
+			continue
+		}
+		have[id] = true
+		if err := tx.Create(&models.UserRole{UserID: userID, RoleID: id}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRoles replaces a user's roles.
+func setRoles(tx *gorm.DB, userID uuid.UUID, roleIDs []uuid.UUID) error {
+	if err := checkRoles(tx, roleIDs); err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+		return err
+	}
+	return addRoles(tx, userID, roleIDs...)
+}
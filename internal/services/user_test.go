@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go-rest/internal/config"
+	"go-rest/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateRefusesUsersWhoOutrankTheCaller(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	createUser(t, db, "root", "admin")
+	admin := createUser(t, db, "admin2", "admin")
+	viewer := createUser(t, db, "viewer", "viewer")
+	users := NewUserService(db, config.Default(), NewPermissionCache(db, 0))
+
+	// A user manager who runs the catalogue but holds no RBAC permissions
+	held := rolePermissions(t, db, "manager")
+	held["users:*"] = true
+
+	email := "attacker@example.com"
+	if _, err := users.Update(admin.ID, UserUpdate{Email: &email}, held); !errors.Is(err, ErrUserOutranks) {
+		t.Errorf("changing an admin's email: err = %v, want ErrUserOutranks", err)
+	}
+	roles := []uuid.UUID{roleID(t, db, "viewer")}
+	if _, err := users.Update(admin.ID, UserUpdate{RoleIDs: &roles}, held); !errors.Is(err, ErrUserOutranks) {
+		t.Errorf("changing an admin's roles: err = %v, want ErrUserOutranks", err)
+	}
+	disabled := true
+	if _, err := users.Update(admin.ID, UserUpdate{Disabled: &disabled}, held); !errors.Is(err, ErrUserOutranks) {
+		t.Errorf("disabling an admin: err = %v, want ErrUserOutranks", err)
+	}
+	if err := users.Delete(admin.ID, held); !errors.Is(err, ErrUserOutranks) {
+		t.Errorf("deleting an admin: err = %v, want ErrUserOutranks", err)
+	}
+
+	updated, err := users.Update(viewer.ID, UserUpdate{Email: &email}, held)
+	if err != nil {
+		t.Fatalf("changing a viewer's email: %v", err)
+	}
+	if updated.Email == nil || *updated.Email != email {
+		t.Errorf("email = %v, want %s", updated.Email, email)
+	}
+	if _, err := users.Update(viewer.ID, UserUpdate{Disabled: &disabled}, held); err != nil {
+		t.Errorf("disabling a viewer: %v", err)
+	}
+	if err := users.Delete(viewer.ID, held); err != nil {
+		t.Errorf("deleting a viewer: %v", err)
+	}
+}
+
+func TestRegisterBootstrapsOneAdmin(t *testing.T) {
+	db := newTestDB(t)
+	users := NewUserService(db, config.Default(), NewPermissionCache(db, 0))
+
+	const registrations = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, registrations)
+	for i := 0; i < registrations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := users.Register(NewUser{Username: fmt.Sprintf("user%d", i), Password: "Secret-Passw0rd"})
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	var admins int64
+	err := db.Model(&models.UserRole{}).
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.name = ?", config.Default().Registration.AdminRole).
+		Count(&admins).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admins != 1 {
+		t.Errorf("%d users were made admin, want 1", admins)
+	}
+}
+
+func TestRegisterDoesNotBootstrapTwice(t *testing.T) {
+	db := newTestDB(t)
+	users := NewUserService(db, config.Default(), NewPermissionCache(db, 0))
+
+	// Another registration became the first admin after this one counted
+	// no users.
+	if err := db.Create(&models.AdminBootstrap{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.Register(NewUser{Username: "second", Password: "Secret-Passw0rd"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	var count int64
+	if err := db.Model(&models.User{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("%d users were created, want 0", count)
+	}
+}
+
+func TestDeletedUsersKeepTheirEmail(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	users := NewUserService(db, config.Default(), NewPermissionCache(db, 0))
+	gone := createUser(t, db, "gone")
+	if err := users.Delete(gone.ID, map[string]bool{"*:*": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := users.Create(NewUser{Username: "new", Password: "Secret-Passw0rd", Email: *gone.Email})
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("creating a user with a deleted user's email: err = %v, want ErrEmailTaken", err)
+	}
+	other := createUser(t, db, "other")
+	if _, err := users.Update(other.ID, UserUpdate{Email: gone.Email}, map[string]bool{"*:*": true}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("taking a deleted user's email: err = %v, want ErrEmailTaken", err)
+	}
+}
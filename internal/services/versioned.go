@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"go-rest/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// conflictRetries bounds how often an internal stock change is retried after
+// losing a race with another writer.
+const conflictRetries = 5
+
+// versioned is a model updated under an optimistic lock.
+type versioned interface {
+	LockVersion() *models.Versioned
+}
+
+// updateVersioned saves every column of record, but only if the stored row
+// still has the version record was read with. It bumps the version on
+// success and returns ErrConflict, leaving record unchanged, otherwise.
+// Associations are not saved.
+func updateVersioned(tx *gorm.DB, record versioned) error {
+	lock := record.LockVersion()
+	read := lock.Version
+	lock.Version++
+
+	result := tx.Model(record).Where("version = ?", read).Select("*").Omit(clause.Associations).Updates(record)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrConflict
+	}
+	if result.Error != nil {
+		lock.Version = read
+	}
+	return result.Error
+}
+
+// checkVersion fails with ErrConflict if a client sent the version it last
+// read and the record has changed since. A zero version is not checked.
+func checkVersion(record versioned, version int) error {
+	if version != 0 && version != record.LockVersion().Version {
+		return ErrConflict
+	}
+	return nil
+}
+
+// retryOnConflict runs fn again, from scratch, while it fails with
+// ErrConflict. fn must re-read whatever it changes.
+func retryOnConflict(fn func() error) error {
+	var err error
+	for attempt := 0; attempt < conflictRetries; attempt++ {
+		if err = fn(); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// isDuplicate reports whether err is a unique constraint violation.
+func isDuplicate(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
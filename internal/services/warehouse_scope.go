@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"go-rest/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrWarehouseForbidden = errors.New("warehouse is outside your assignment")
+
+// The all_warehouses:access permission lets a role bypass warehouse
+// scoping. It is a resource of its own so that warehouses:*, which manages
+// warehouse records, does not imply it.
+const (
+	AllWarehousesResource = "all_warehouses"
+	AllWarehousesAction   = "access"
+)
+
+func init() {
+	RegisterPermission(AllWarehousesResource, AllWarehousesAction)
+}
+
+// WarehouseScope is the set of warehouses a request may touch. A nil scope
+// is unrestricted; it is what internal callers use.
+type WarehouseScope struct {
+	All bool
+	IDs []uuid.UUID
+}
+
+// Allows reports whether the scope covers a warehouse.
+func (s *WarehouseScope) Allows(warehouseID uuid.UUID) bool {
+	if s == nil || s.All {
+		return true
+	}
+	for _, id := range s.IDs {
+		if id == warehouseID {
+			return true
+		}
+	}
+	return false
+}
+
+// Check fails with ErrWarehouseForbidden unless every warehouse is covered.
+func (s *WarehouseScope) Check(warehouseIDs ...uuid.UUID) error {
+	for _, id := range warehouseIDs {
+		if !s.Allows(id) {
+			return ErrWarehouseForbidden
+		}
+	}
+	return nil
+}
+
+// Filter restricts a query to rows whose column is a covered warehouse.
+func (s *WarehouseScope) Filter(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if s == nil || s.All {
+			return db
+		}
+		if len(s.IDs) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(column+" IN ?", s.IDs)
+	}
+}
+
+// ResolveWarehouseScope returns the scope of a user holding perms: every
+// warehouse with the bypass permission, otherwise only their assignments.
+func ResolveWarehouseScope(db *gorm.DB, userID uuid.UUID, perms map[string]bool) (*WarehouseScope, error) {
+	if HasPermission(perms, AllWarehousesResource, AllWarehousesAction) {
+		return &WarehouseScope{All: true}, nil
+	}
+
+	var ids []uuid.UUID
+	err := db.Model(&models.UserWarehouse{}).Where("user_id = ?", userID).Pluck("warehouse_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return &WarehouseScope{IDs: ids}, nil
+}
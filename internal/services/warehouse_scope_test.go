@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"go-rest/internal/models"
+)
+
+func TestWarehouseBypassIsNotWarehouseCRUD(t *testing.T) {
+	db := newTestDB(t)
+	seedRoles(t, db)
+	user := createUser(t, db, "erin")
+	assigned := models.Warehouse{Name: "North"}
+	other := models.Warehouse{Name: "South"}
+	if err := db.Create(&assigned).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.UserWarehouse{UserID: user.ID, WarehouseID: assigned.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		perms map[string]bool
+		all   bool
+	}{
+		{map[string]bool{"warehouses:*": true}, false},
+		{map[string]bool{"all_warehouses:access": true}, true},
+		{map[string]bool{"*:*": true}, true},
+	}
+	for _, test := range tests {
+		scope, err := ResolveWarehouseScope(db, user.ID, test.perms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scope.All != test.all {
+			t.Errorf("%v: unrestricted = %v, want %v", test.perms, scope.All, test.all)
+		}
+		if !scope.Allows(assigned.ID) {
+			t.Errorf("%v: scope does not cover an assigned warehouse", test.perms)
+		}
+		if scope.Allows(other.ID) != test.all {
+			t.Errorf("%v: covers an unassigned warehouse = %v, want %v", test.perms, scope.Allows(other.ID), test.all)
+		}
+	}
+}